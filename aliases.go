@@ -0,0 +1,165 @@
+package krait
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadAliasFile loads user aliases from a config file. See LoadAliases for
+// the format.
+func (fs *FlagSet) LoadAliasFile(path string) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = fs.LoadAliases(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadAliases loads git style user aliases, one per line, such as
+//
+//	# Aliases for myapp
+//	st = "status --short"
+//	lg = log --oneline -n 20
+//	remote ls = "list --verbose"
+//
+// Blank lines, comments starting with # or ; and [section] headers are
+// ignored. Every alias that conflicts with a command is skipped and reported
+// in a single ErrAliasConflict error once the rest are loaded.
+func (fs *FlagSet) LoadAliases(r io.Reader) (err error) {
+	var conflicts []string
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		name, expansion, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		expansion = strings.TrimSpace(expansion)
+		if !ok {
+			return fmt.Errorf("line %d: invalid alias %q: expected name = \"command\"", n, line)
+		}
+		if strings.HasPrefix(expansion, `"`) {
+			if expansion, err = strconv.Unquote(expansion); err != nil {
+				return fmt.Errorf("line %d: invalid alias %q: %v", n, line, err)
+			}
+		}
+
+		if err = fs.UserAlias(name, expansion); errors.Is(err, ErrAliasConflict) {
+			conflicts = append(conflicts, strconv.Quote(name))
+		} else if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrAliasConflict, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// UserAlias defines a user alias that expands to a command line, such as
+// "st" for "status --short". Any arguments following the alias are appended
+// to the expansion. The name may be preceded by the path of a subcommand,
+// such as "remote ls" for "list --verbose", to expand in place of the
+// subcommands of that subcommand. Aliases may expand to other aliases of the
+// same subcommand but never to themselves, and never replace a command or its
+// aliases.
+func (fs *FlagSet) UserAlias(name string, expansion string) (err error) {
+	path := strings.Fields(name)
+	if len(path) == 0 {
+		return fmt.Errorf("invalid alias name %q", name)
+	}
+	for _, word := range path {
+		if strings.HasPrefix(word, "-") {
+			return fmt.Errorf("invalid alias name %q", name)
+		}
+	}
+	alias := path[len(path)-1]
+
+	parent, ok := fs.Command(path[:len(path)-1]...)
+	if !ok {
+		return fmt.Errorf("invalid alias %q: unknown command %q", name, strings.Join(path[:len(path)-1], " "))
+	}
+	if _, ok = parent.argIsSubcommand(alias); ok {
+		return fmt.Errorf("%w: %q", ErrAliasConflict, name)
+	}
+
+	words, err := SplitCommandLine(expansion)
+	if err != nil {
+		return fmt.Errorf("invalid alias %q: %v", name, err)
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("invalid alias %q: empty expansion", name)
+	}
+
+	if parent.userAliases == nil {
+		parent.userAliases = make(map[string]string)
+	}
+	parent.userAliases[alias] = expansion
+
+	return nil
+}
+
+// allUserAliases returns the expansion of every user alias in the tree keyed
+// by its name, preceded by the path of its subcommand below the root
+func (fs *FlagSet) allUserAliases() (aliases map[string]string) {
+	aliases = make(map[string]string)
+	for _, cfs := range fs.getRoot().getCommandTree() {
+		prefix := ""
+		if path := cfs.getCommandList()[1:]; len(path) > 0 {
+			prefix = strings.Join(path, " ") + " "
+		}
+		for name, expansion := range cfs.userAliases {
+			aliases[prefix+name] = expansion
+		}
+	}
+	return aliases
+}
+
+// expandAliases replaces a user alias of this FlagSet in the first of args,
+// the word at level on the command line, with its expansion, repeating until
+// the first word is not an alias
+func (fs *FlagSet) expandAliases(args []string, level int) (expanded []string, err error) {
+	expanded = args
+	var chain []string
+
+	for len(expanded) > 0 {
+		name := expanded[0]
+		expansion, ok := fs.userAliases[name]
+		if !ok {
+			break
+		}
+		if _, registered := fs.argIsSubcommand(name); registered {
+			break
+		}
+
+		for _, seen := range chain {
+			if seen == name {
+				chain = append(chain, name)
+				return args, fs.parseError(ErrAliasRecursion, name, level, "%s: %s", ErrorAliasRecursion, strings.Join(chain, " -> "))
+			}
+		}
+		chain = append(chain, name)
+
+		words, _ := SplitCommandLine(expansion) // Validated by UserAlias
+		expanded = append(words, expanded[1:]...)
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,93 @@
+package krait
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testAliases = `# Aliases for krait
+[alias]
+st = "status --short"
+loop = again
+again = loop
+help = "status"
+ver = status
+`
+
+// TestUserAliases ensure user aliases expand with argument splicing and
+// recursion protection
+func TestUserAliases(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	statusFS := root.NewFlagSet("status")
+	short := statusFS.OptionBool([]string{"s", "short"}, false, "Short output")
+
+	err := root.LoadAliases(strings.NewReader(testAliases))
+	if !errors.Is(err, ErrAliasConflict) || err.Error() != `alias conflicts with a command: "help", "ver"` {
+		t.Fatalf("got: %v | want: %v", err, ErrAliasConflict)
+	}
+
+	subcmd, err := root.Parse([]string{"krait", "st", "one"})
+	if err != nil || subcmd != "status" || !*short || fmt.Sprint(root.Args()) != "[one]" {
+		t.Fatalf("got: %q %v short: %t args: %q", subcmd, err, *short, root.Args())
+	}
+
+	_, err = root.Parse([]string{"krait", "loop"})
+	if !errors.Is(err, ErrAliasRecursion) || err.Error() != "recursive alias: loop -> again -> loop" {
+		t.Fatalf("got: %v | want: %v", err, ErrAliasRecursion)
+	}
+
+	var buf bytes.Buffer
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+
+	root.Parse([]string{"krait", "help"})
+	if want := "USER ALIASES\n------------\n  again            \"loop\"\n"; !strings.Contains(buf.String(), want) {
+		t.Fatalf("got:\n%s\nwant: %q", buf.String(), want)
+	}
+}
+
+// TestNestedUserAliases ensure aliases of subcommands of subcommands expand at
+// their own level
+func TestNestedUserAliases(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	remoteFS := root.NewFlagSet("remote")
+	statusFS := remoteFS.NewFlagSet("status")
+	short := statusFS.OptionBool([]string{"s", "short"}, false, "Short output")
+
+	aliases := "rst = remote st\nremote st = \"status --short\"\nremote loop = loop\n"
+	if err := root.LoadAliases(strings.NewReader(aliases)); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.UserAlias("remote status", "status"); !errors.Is(err, ErrAliasConflict) {
+		t.Fatalf("got: %v | want: %v", err, ErrAliasConflict)
+	}
+	if err := root.UserAlias("tag st", "status"); err == nil || err.Error() != `invalid alias "tag st": unknown command "tag"` {
+		t.Fatalf("got: %v | want: unknown command", err)
+	}
+
+	for _, args := range [][]string{{"krait", "remote", "st", "one"}, {"krait", "rst", "one"}} {
+		subcmd, err := root.Parse(args)
+		if err != nil || subcmd != "status" || !*short || fmt.Sprint(root.Args()) != "[one]" {
+			t.Fatalf("%q got: %q %v short: %t args: %q", args, subcmd, err, *short, root.Args())
+		}
+	}
+
+	_, err := root.Parse([]string{"krait", "remote", "loop"})
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrAliasRecursion) || perr.Position != 2 {
+		t.Fatalf("got: %v | want: %v at 2", err, ErrAliasRecursion)
+	}
+
+	var buf bytes.Buffer
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+
+	root.Parse([]string{"krait", "help"})
+	if want := "  remote st         \"status --short\"\n"; !strings.Contains(buf.String(), want) {
+		t.Fatalf("got:\n%s\nwant: %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,195 @@
+package krait
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Bind registers an option or named argument for every exported field of the
+// struct pointed to by v and fills the fields once Parse succeeds, before
+// CmdFunc is called. Fields are configured with struct tags:
+//
+//	type deployOptions struct {
+//		Count  int               `krait:"c,count" usage:"Number of replicas" default:"1" env:"MYAPP_COUNT"`
+//		DryRun bool              `usage:"Show what would be done"`
+//		Labels map[string]string `krait:"l,label" usage:"Labels to apply"`
+//		Target string            `arg:"0" name:"TARGET" usage:"Where to deploy"`
+//		Files  []string          `arg:"1" usage:"Files to deploy" required:"true"`
+//	}
+//
+// The krait tag lists the option aliases, the kebab-case field name by
+// default, or "-" to skip the field. Fields tagged with arg are named
+// positional arguments in index order instead, the indexes counting up from 0
+// without gaps. A string option tagged novalue has an optional
+// option-argument, see OptionStringOptional. A []string argument is variadic
+// and only requires a value when tagged required. Field types are bool,
+// float64, int, map[string]string, string, uint, or any type whose pointer
+// implements flag.Value, and ResettableValue if it accumulates values.
+func (fs *FlagSet) Bind(v any) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("cannot bind %T: expected a pointer to a struct", v))
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	type positional struct {
+		field reflect.StructField
+		index int
+		value reflect.Value
+	}
+	var args []positional
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() || field.Tag.Get("krait") == "-" {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("arg"); ok {
+			index, err := strconv.Atoi(tag)
+			if err != nil {
+				panic(fmt.Sprintf("cannot bind field %s: invalid arg index %q", field.Name, tag))
+			}
+			args = append(args, positional{field: field, index: index, value: rv.Field(i)})
+			continue
+		}
+
+		fs.bindOption(field, rv.Field(i))
+	}
+
+	// Indexes must run from 0 without duplicates or gaps
+	sort.SliceStable(args, func(i, j int) bool { return args[i].index < args[j].index })
+	for i, arg := range args {
+		if i > 0 && arg.index == args[i-1].index {
+			panic(fmt.Sprintf("cannot bind field %s: arg index %d already used by %s", arg.field.Name, arg.index, args[i-1].field.Name))
+		}
+		if arg.index != i {
+			panic(fmt.Sprintf("cannot bind field %s: arg index %d is not contiguous, expected %d", arg.field.Name, arg.index, i))
+		}
+	}
+	for _, arg := range args {
+		fs.bindArg(arg.field, arg.value)
+	}
+}
+
+// bindArg registers the named argument for a struct field
+func (fs *FlagSet) bindArg(field reflect.StructField, value reflect.Value) {
+	name := field.Tag.Get("name")
+	if name == "" {
+		name = strings.ToUpper(kebabCase(field.Name))
+	}
+	usage := field.Tag.Get("usage")
+
+	var get func() any
+	switch value.Interface().(type) {
+	case float64:
+		a := fs.ArgFloat(name, usage)
+		get = func() any { return *a }
+	case int:
+		a := fs.ArgInt(name, usage)
+		get = func() any { return *a }
+	case string:
+		a := fs.Arg(name, usage)
+		get = func() any { return *a }
+	case []string:
+		min := 0
+		if field.Tag.Get("required") == "true" {
+			min = 1
+		}
+		a := fs.ArgList(name, min, -1, usage)
+		get = func() any { return *a }
+	case uint:
+		a := fs.ArgUint(name, usage)
+		get = func() any { return *a }
+	default:
+		panic(fmt.Sprintf("cannot bind field %s: unsupported argument type %s", field.Name, field.Type))
+	}
+
+	fs.bindings = append(fs.bindings, func() {
+		value.Set(reflect.ValueOf(get()))
+	})
+}
+
+// bindOption registers the option for a struct field
+func (fs *FlagSet) bindOption(field reflect.StructField, value reflect.Value) {
+	aliases := strings.Split(field.Tag.Get("krait"), ",")
+	if aliases[0] == "" {
+		aliases = []string{kebabCase(field.Name)}
+	}
+	usage := field.Tag.Get("usage")
+
+	var get func() any
+	switch v := value.Addr().Interface().(type) {
+	case flag.Value:
+		fs.OptionValue(aliases, v, usage)
+	case *bool:
+		o := fs.OptionBool(aliases, *v, usage)
+		get = func() any { return *o }
+	case *float64:
+		o := fs.OptionFloat(aliases, *v, usage)
+		get = func() any { return *o }
+	case *int:
+		o := fs.OptionInt(aliases, *v, usage)
+		get = func() any { return *o }
+	case *map[string]string:
+		o := fs.OptionMap(aliases, usage)
+		get = func() any { return o }
+	case *string:
+		var o *string
+		if noValue, ok := field.Tag.Lookup("novalue"); ok {
+			o = fs.OptionStringOptional(aliases, *v, noValue, usage)
+		} else {
+			o = fs.OptionString(aliases, *v, usage)
+		}
+		get = func() any { return *o }
+	case *uint:
+		o := fs.OptionUint(aliases, *v, usage)
+		get = func() any { return *o }
+	default:
+		panic(fmt.Sprintf("cannot bind field %s: unsupported option type %s", field.Name, field.Type))
+	}
+
+	name, _ := fs.optionName(aliases[0])
+	if def, ok := field.Tag.Lookup("default"); ok {
+		f := fs.flagSet.Lookup(name)
+		if err := f.Value.Set(def); err != nil {
+			panic(fmt.Sprintf("cannot bind field %s: invalid default %q: %v", field.Name, def, err))
+		}
+		f.DefValue = f.Value.String()
+	}
+	if env := field.Tag.Get("env"); env != "" {
+		fs.Env(name, env)
+	}
+	if field.Tag.Get("required") == "true" {
+		fs.Require(name)
+	}
+
+	if get != nil {
+		fs.bindings = append(fs.bindings, func() {
+			value.Set(reflect.ValueOf(get()))
+		})
+	}
+}
+
+// kebabCase converts a Go field name such as DryRun to dry-run
+func kebabCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word unless within an initialism such as URL
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
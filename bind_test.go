@@ -0,0 +1,117 @@
+package krait
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// level is a flag.Value used to test the generic Value path
+type level string
+
+func (l *level) Set(s string) error {
+	if s != "debug" && s != "info" {
+		return errors.New("expected debug or info")
+	}
+	*l = level(s)
+	return nil
+}
+
+func (l *level) String() string {
+	return string(*l)
+}
+
+type deployOptions struct {
+	Count   int               `krait:"c,count" usage:"Number of replicas" default:"1" env:"KRAIT_TEST_COUNT"`
+	DryRun  bool              `usage:"Show what would be done"`
+	Labels  map[string]string `krait:"l,label" usage:"Labels to apply"`
+	Level   level             `usage:"Log level"`
+	Token   string            `required:"true" usage:"API token"`
+	Target  string            `arg:"0" name:"TARGET" usage:"Where to deploy"`
+	Files   []string          `arg:"1" usage:"Files to deploy"`
+	ignored string
+}
+
+// TestBind ensure struct fields are registered from their tags and filled
+// after Parse
+func TestBind(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	deployFS := root.NewFlagSet("deploy")
+
+	var opts deployOptions
+	deployFS.Bind(&opts)
+
+	var atCmd string
+	deployFS.CmdFunc = func(fs *FlagSet, args ...string) {
+		atCmd = fmt.Sprintf("%d %s", opts.Count, opts.Target)
+	}
+
+	t.Setenv("KRAIT_TEST_COUNT", "4")
+	_, err := root.Parse([]string{"krait", "deploy", "--dry-run", "-l", "a=b", "--level=debug", "--token=x", "prod", "one", "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := fmt.Sprintf("%d %t %v %s %s %s %q", opts.Count, opts.DryRun, opts.Labels, opts.Level, opts.Token, opts.Target, opts.Files)
+	if want := `4 true map[a:b] debug x prod ["one" "two"]`; got != want {
+		t.Fatalf("got: %s | want: %s", got, want)
+	}
+	if atCmd != "4 prod" {
+		t.Fatalf("got at CmdFunc: %q", atCmd)
+	}
+
+	t.Setenv("KRAIT_TEST_COUNT", "many")
+	_, err = root.Parse([]string{"krait", "deploy", "--token=x", "prod"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Source != SourceEnv+" KRAIT_TEST_COUNT" {
+		t.Fatalf("got: %v | want an environment variable validation error", err)
+	}
+
+	t.Setenv("KRAIT_TEST_COUNT", "2")
+	_, err = root.Parse([]string{"krait", "deploy", "prod"})
+	if err == nil || !strings.Contains(err.Error(), "--token") {
+		t.Fatalf("got: %v | want missing --token", err)
+	}
+}
+
+// TestBindArgIndexes ensure duplicate and missing arg indexes panic at bind time
+func TestBindArgIndexes(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"duplicate", &struct {
+			Source string `arg:"0"`
+			Target string `arg:"0"`
+		}{}, "cannot bind field Target: arg index 0 already used by Source"},
+		{"gap", &struct {
+			Source string `arg:"0"`
+			Target string `arg:"2"`
+		}{}, "cannot bind field Target: arg index 2 is not contiguous, expected 1"},
+		{"start", &struct {
+			Target string `arg:"1"`
+		}{}, "cannot bind field Target: arg index 1 is not contiguous, expected 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if got := fmt.Sprint(recover()); got != tt.want {
+					t.Errorf("got: %s | want: %s", got, tt.want)
+				}
+			}()
+			NewFlagSet("krait", ContinueOnError).NewFlagSet("copy").Bind(tt.v)
+		})
+	}
+}
+
+// TestKebabCase ensure field names become option names
+func TestKebabCase(t *testing.T) {
+	for name, want := range map[string]string{"Count": "count", "DryRun": "dry-run", "APIURL": "apiurl", "BaseURLPath": "base-url-path"} {
+		if got := kebabCase(name); got != want {
+			t.Fatalf("%s got: %s | want: %s", name, got, want)
+		}
+	}
+}
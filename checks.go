@@ -0,0 +1,113 @@
+package krait
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	SourceCommandLine = "command line"
+	SourceConfig      = "config file" // Followed by the path, e.g. config file /etc/myapp.conf
+	SourceDefault     = "default"
+	SourceEnv         = "environment variable" // Followed by the variable name, e.g. environment variable MYAPP_COUNT
+)
+
+// OptionCheck validates and optionally transforms an option value. Checks
+// are passed to the Option* methods and run in order once the command line
+// has been parsed. Each check returns the value handed to the next check, so
+// a validator returns its input unchanged while a transformer such as
+// TrimSpace returns the normalized value. A transformed value replaces the
+// option value with Set, so an OptionValue that accumulates values must
+// implement ResettableValue to be emptied first.
+type OptionCheck func(value string) (string, error)
+
+// ValidationError reports an option value rejected by an OptionCheck
+type ValidationError struct {
+	Option string // Option name with its aliases, e.g. --count (-c)
+	Source string // Where the value came from, e.g. "command line"
+	Value  string // The rejected value
+	Err    error  // The error returned by the check
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid value %q for option %s from %s: %v", e.Value, e.Option, e.Source, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ExpandHome transforms a leading ~ into the current user's home directory
+func ExpandHome(value string) (string, error) {
+	if value != "~" && !strings.HasPrefix(value, "~/") {
+		return value, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return value, err
+	}
+	return filepath.Join(home, value[1:]), nil
+}
+
+// ToLower transforms the value to lower case
+func ToLower(value string) (string, error) {
+	return strings.ToLower(value), nil
+}
+
+// TrimSpace transforms the value by removing leading and trailing white space
+func TrimSpace(value string) (string, error) {
+	return strings.TrimSpace(value), nil
+}
+
+// ValidateFileExists validates that the value names an existing file or
+// directory
+func ValidateFileExists(value string) (string, error) {
+	if _, err := os.Stat(value); err != nil {
+		return value, fmt.Errorf("file does not exist")
+	}
+	return value, nil
+}
+
+// ValidateMatch returns a check validating that the value matches the regular
+// expression pattern. It panics if pattern does not compile.
+func ValidateMatch(pattern string) OptionCheck {
+	re := regexp.MustCompile(pattern)
+	return func(value string) (string, error) {
+		if !re.MatchString(value) {
+			return value, fmt.Errorf("does not match %s", pattern)
+		}
+		return value, nil
+	}
+}
+
+// ValidateOneOf returns a check validating that the value is one of choices
+func ValidateOneOf(choices ...string) OptionCheck {
+	return func(value string) (string, error) {
+		for _, choice := range choices {
+			if value == choice {
+				return value, nil
+			}
+		}
+		return value, fmt.Errorf("must be one of %s", strings.Join(choices, ", "))
+	}
+}
+
+// ValidateRange returns a check validating that the value is a number between
+// min and max inclusive
+func ValidateRange(min float64, max float64) OptionCheck {
+	return func(value string) (string, error) {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return value, fmt.Errorf("not a number")
+		}
+		if n < min || n > max {
+			return value, fmt.Errorf("must be between %s and %s", strconv.FormatFloat(min, 'f', -1, 64), strconv.FormatFloat(max, 'f', -1, 64))
+		}
+		return value, nil
+	}
+}
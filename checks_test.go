@@ -0,0 +1,63 @@
+package krait
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOptionChecksValidate ensure failed validators produce a ValidationError
+func TestOptionChecksValidate(t *testing.T) {
+	args := []string{"krait", "test", "-c", "11"}
+
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test", ContinueOnError)
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'", ValidateRange(1, 10))
+	_, err := root.Parse(args)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got: %v | want: *ValidationError", err)
+	}
+	if verr.Option != "--count (-c)" || verr.Source != SourceCommandLine || verr.Value != "11" {
+		t.Fatalf("got: %#v", verr)
+	}
+}
+
+// TestOptionChecksTransform ensure transformers normalize option values in order
+func TestOptionChecksTransform(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	args := []string{"krait", "test", "--level=  DEBUG ", "--path=~/krait"}
+
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test", ContinueOnError)
+	level := testFS.OptionStringOptional([]string{"level"}, "info", "info", "Log level", TrimSpace, ToLower, ValidateOneOf("debug", "info"))
+	path := testFS.OptionStringOptional([]string{"path"}, "", "", "Path", ExpandHome)
+	_, err = root.Parse(args)
+
+	if err != nil || *level != "debug" || *path != filepath.Join(home, "krait") {
+		t.Fatalf("got: %q %q (%v)", *level, *path, err)
+	}
+}
+
+// TestOptionChecksTransformAccumulating ensure a transformed accumulating
+// value replaces the original rather than adding to it
+func TestOptionChecksTransformAccumulating(t *testing.T) {
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test", ContinueOnError)
+	tags := &listValue{}
+	testFS.OptionValue([]string{"t", "tag"}, tags, "Tags", ToLower)
+	labels := testFS.OptionMap([]string{"l", "label"}, "Labels", ToLower)
+
+	if _, err := root.Parse([]string{"krait", "test", "-t", "A", "-t", "B", "-l", "K=V"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%q %v", *tags, labels); got != `["a" "b"] map[k:v]` {
+		t.Fatalf("got: %s | want: [\"a\" \"b\"] map[k:v]", got)
+	}
+}
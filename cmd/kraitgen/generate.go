@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/runeimp/krait"
+)
+
+// command is a subcommand of the spec prepared for the templates
+type command struct {
+	Aliases  []string
+	Epilogue string
+	Fields   []field
+	Name     string
+	NArgs    *int
+	Parent   string // Variable of the parent FlagSet
+	Path     string // Full command path, e.g. myapp remote add
+	Summary  string
+	Type     string // Go name, e.g. RemoteAdd
+	Var      string // Variable name prefix, e.g. remoteAdd
+}
+
+// field is an options struct field prepared for the templates
+type field struct {
+	Name string
+	Tag  string
+	Type string
+}
+
+// templateData is passed to the templates
+type templateData struct {
+	AppLabel          string
+	Commands          []command
+	DefaultSubCommand *string
+	Epilogue          string
+	ErrorHandling     string
+	Name              string
+	Package           string
+	Source            string
+}
+
+var errorHandlingNames = map[string]string{
+	"":         "ExitOnError",
+	"continue": "ContinueOnError",
+	"exit":     "ExitOnError",
+	"panic":    "PanicOnError",
+}
+
+var fieldTypes = map[string]string{
+	"":                 "string",
+	"float":            "float64",
+	krait.OptionBool:   "bool",
+	krait.OptionFloat:  "float64",
+	krait.OptionInt:    "int",
+	krait.OptionMap:    "map[string]string",
+	krait.OptionString: "string",
+	krait.OptionUint:   "uint",
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by kraitgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/runeimp/krait"
+
+// Handlers are called for the commands of {{.Name}} with their parsed options
+type Handlers interface {
+{{- range .Commands}}
+	// {{.Type}} handles "{{.Path}}"
+	{{.Type}}(fs *krait.FlagSet, opts *{{.Type}}Options, args ...string)
+{{- end}}
+}
+{{range .Commands}}
+// {{.Type}}Options are the options of "{{.Path}}"
+type {{.Type}}Options struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} {{.Tag}}
+{{- end}}
+}
+{{end}}
+// NewCLI builds the {{.Name}} command tree calling h for each command
+func NewCLI(h Handlers) *krait.FlagSet {
+	root := krait.NewFlagSet({{printf "%q" .Name}}, krait.{{.ErrorHandling}})
+{{- if .AppLabel}}
+	root.AppLabel = {{printf "%q" .AppLabel}}
+{{- end}}
+{{- if .DefaultSubCommand}}
+	root.DefaultSubCommand = {{printf "%q" .DefaultSubCommand}}
+{{- end}}
+{{- if .Epilogue}}
+	root.Epilogue = {{printf "%q" .Epilogue}}
+{{- end}}
+{{range .Commands}}
+	{{.Var}}FS := {{.Parent}}.NewFlagSet({{printf "%q" .Name}})
+{{- if .Summary}}
+	{{.Var}}FS.Summery = {{printf "%q" .Summary}}
+{{- end}}
+{{- if .Epilogue}}
+	{{.Var}}FS.Epilogue = {{printf "%q" .Epilogue}}
+{{- end}}
+{{- if .NArgs}}
+	{{.Var}}FS.NArgs = {{.NArgs}}
+{{- end}}
+{{- if .Aliases}}
+	{{.Var}}FS.SubcommandAlias({{range $i, $a := .Aliases}}{{if $i}}, {{end}}{{printf "%q" $a}}{{end}})
+{{- end}}
+	{{.Var}}Opts := new({{.Type}}Options)
+	{{.Var}}FS.Bind({{.Var}}Opts)
+	{{.Var}}FS.CmdFunc = func(fs *krait.FlagSet, args ...string) {
+		h.{{.Type}}(fs, {{.Var}}Opts, args...)
+	}
+{{end}}
+	return root
+}
+`))
+
+var stubsTemplate = template.Must(template.New("stubs").Parse(`package {{.Package}}
+
+import "github.com/runeimp/krait"
+
+// handlers implements the commands of {{.Name}}
+type handlers struct{}
+
+var _ Handlers = handlers{}
+{{range .Commands}}
+// {{.Type}} handles "{{.Path}}"
+func (handlers) {{.Type}}(fs *krait.FlagSet, opts *{{.Type}}Options, args ...string) {
+	// TODO: implement {{.Path}}
+}
+{{end}}`))
+
+// readSpec decodes a spec already validated by krait.FromSpec
+func readSpec(data []byte) (spec krait.Spec, err error) {
+	err = json.Unmarshal(data, &spec)
+	return spec, err
+}
+
+// generate returns the formatted Go code for the spec
+func generate(spec krait.Spec, pkg string, source string) (code []byte, err error) {
+	data, err := newTemplateData(spec, pkg, source)
+	if err != nil {
+		return nil, err
+	}
+	return execute(codeTemplate, data)
+}
+
+// generateStubs returns formatted handler stubs for the spec
+func generateStubs(spec krait.Spec, pkg string) (code []byte, err error) {
+	data, err := newTemplateData(spec, pkg, "")
+	if err != nil {
+		return nil, err
+	}
+	return execute(stubsTemplate, data)
+}
+
+func execute(t *template.Template, data templateData) (code []byte, err error) {
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	if code, err = format.Source(buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return code, nil
+}
+
+func newTemplateData(spec krait.Spec, pkg string, source string) (data templateData, err error) {
+	if len(spec.Options) > 0 {
+		return data, fmt.Errorf("options of the root command are not supported, define them on a command")
+	}
+
+	errorHandling, ok := errorHandlingNames[strings.ToLower(spec.ErrorHandling)]
+	if !ok {
+		return data, fmt.Errorf("unknown errorHandling %q", spec.ErrorHandling)
+	}
+
+	data = templateData{
+		AppLabel:          spec.AppLabel,
+		DefaultSubCommand: spec.DefaultSubCommand,
+		Epilogue:          spec.Epilogue,
+		ErrorHandling:     errorHandling,
+		Name:              spec.Name,
+		Package:           pkg,
+		Source:            source,
+	}
+	if data.Commands, err = commands(spec.Commands, "root", spec.Name, ""); err != nil {
+		return data, err
+	}
+
+	// Command paths such as "remote-add" and "remote add" share a Go name
+	paths := make(map[string]string)
+	for _, cmd := range data.Commands {
+		if other, ok := paths[cmd.Type]; ok {
+			return data, fmt.Errorf("commands %q and %q both generate the Go name %s", other, cmd.Path, cmd.Type)
+		}
+		paths[cmd.Type] = cmd.Path
+	}
+	return data, nil
+}
+
+// commands flattens the command tree depth first
+func commands(specs []krait.CommandSpec, parent string, path string, typePrefix string) (cmds []command, err error) {
+	for _, spec := range specs {
+		cmd := command{
+			Aliases:  spec.Aliases,
+			Epilogue: spec.Epilogue,
+			Name:     spec.Name,
+			NArgs:    spec.NArgs,
+			Path:     path + " " + spec.Name,
+			Summary:  spec.Summary,
+			Type:     typePrefix + goName(spec.Name),
+		}
+		cmd.Var = lowerFirst(cmd.Type)
+		cmd.Parent = parent
+		if parent != "root" {
+			cmd.Parent = parent + "FS"
+		}
+
+		if cmd.Fields, err = fields(spec.Options); err != nil {
+			return nil, fmt.Errorf("%s: %w", cmd.Path, err)
+		}
+
+		children, err := commands(spec.Commands, cmd.Var, cmd.Path, cmd.Type)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, cmd)
+		cmds = append(cmds, children...)
+	}
+	return cmds, nil
+}
+
+// fields returns the options struct fields for the options
+func fields(options []krait.OptionSpec) (result []field, err error) {
+	for _, o := range options {
+		// The longest alias names the option the way krait does
+		longest := o.Aliases[0]
+		for _, alias := range o.Aliases {
+			if len(alias) >= len(longest) {
+				longest = alias
+			}
+		}
+
+		f := field{Name: goName(longest), Type: fieldTypes[o.Type]}
+		if f.Type == "" {
+			return nil, fmt.Errorf("option %s has unknown type %q", longest, o.Type)
+		}
+
+		tags := []string{"krait:" + strconv.Quote(strings.Join(o.Aliases, ","))}
+		if o.Usage != "" {
+			tags = append(tags, "usage:"+strconv.Quote(o.Usage))
+		}
+		if len(o.Default) > 0 {
+			def, err := defaultString(o.Default, f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("option %s default: %w", longest, err)
+			}
+			tags = append(tags, "default:"+strconv.Quote(def))
+		}
+		if o.Env != "" {
+			tags = append(tags, "env:"+strconv.Quote(o.Env))
+		}
+		if o.NoValue != nil {
+			tags = append(tags, "novalue:"+strconv.Quote(*o.NoValue))
+		}
+		if o.Required {
+			tags = append(tags, `required:"true"`)
+		}
+
+		f.Tag = strings.Join(tags, " ")
+		if strings.Contains(f.Tag, "`") {
+			f.Tag = strconv.Quote(f.Tag)
+		} else {
+			f.Tag = "`" + f.Tag + "`"
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// defaultString converts a JSON default to the string form accepted by
+// flag.Value.Set
+func defaultString(raw json.RawMessage, goType string) (def string, err error) {
+	switch goType {
+	case "map[string]string":
+		var m map[string]string
+		if err = json.Unmarshal(raw, &m); err != nil {
+			return def, err
+		}
+		pairs := make([]string, 0, len(m))
+		for k, v := range m {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	case "string":
+		err = json.Unmarshal(raw, &def)
+		return def, err
+	case "int":
+		var i int64
+		if err = json.Unmarshal(raw, &i); err != nil {
+			return def, err
+		}
+		return strconv.FormatInt(i, 10), nil
+	case "uint":
+		var u uint64
+		if err = json.Unmarshal(raw, &u); err != nil {
+			return def, err
+		}
+		return strconv.FormatUint(u, 10), nil
+	default:
+		var v any
+		if err = json.Unmarshal(raw, &v); err != nil {
+			return def, err
+		}
+		return fmt.Sprint(v), nil
+	}
+}
+
+// goName converts a command or option name such as dry-run to DryRun
+func goName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if s := b.String(); s != "" && !unicode.IsDigit([]rune(s)[0]) {
+		return s
+	}
+	return "X" + b.String()
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
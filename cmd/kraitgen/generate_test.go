@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSpec = `{
+	"name": "myapp",
+	"commands": [
+		{"name": "remote", "nargs": 0, "commands": [
+			{"name": "add", "aliases": ["a"], "nargs": 2, "options": [
+				{"aliases": ["t", "track"], "type": "map", "default": {"branch": "main"}, "usage": "Branches to track"},
+				{"aliases": ["dry-run"], "type": "bool", "required": true}
+			]}
+		]},
+		{"name": "serve", "options": [
+			{"aliases": ["max-body"], "type": "int", "default": 1000000},
+			{"aliases": ["p", "port"], "type": "uint", "default": 8080}
+		]}
+	]
+}`
+
+// TestGenerate ensure typed options structs, handlers and the FlagSet tree
+// are generated from a spec
+func TestGenerate(t *testing.T) {
+	spec, err := readSpec([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := generate(spec, "main", "myapp.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by kraitgen from myapp.json. DO NOT EDIT.",
+		"RemoteAdd(fs *krait.FlagSet, opts *RemoteAddOptions, args ...string)",
+		"Track  map[string]string `krait:\"t,track\" usage:\"Branches to track\" default:\"branch=main\"`",
+		"DryRun bool              `krait:\"dry-run\" required:\"true\"`",
+		"MaxBody int  `krait:\"max-body\" default:\"1000000\"`",
+		"Port    uint `krait:\"p,port\" default:\"8080\"`",
+		"remoteAddFS := remoteFS.NewFlagSet(\"add\")",
+		"remoteAddFS.SubcommandAlias(\"a\")",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Fatalf("generated code is missing %s\n%s", want, code)
+		}
+	}
+
+	stubs, err := generateStubs(spec, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "func (handlers) RemoteAdd(fs *krait.FlagSet, opts *RemoteAddOptions, args ...string) {"; !strings.Contains(string(stubs), want) {
+		t.Fatalf("stubs are missing %s\n%s", want, stubs)
+	}
+}
+
+// TestGenerateDuplicateNames ensure command paths sharing a Go name are
+// reported instead of generating code that does not compile
+func TestGenerateDuplicateNames(t *testing.T) {
+	spec, err := readSpec([]byte(`{"name": "myapp", "commands": [
+		{"name": "remote", "commands": [{"name": "add"}]},
+		{"name": "remote-add"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `commands "myapp remote add" and "myapp remote-add" both generate the Go name RemoteAdd`
+	if _, err = generate(spec, "main", "myapp.json"); err == nil || err.Error() != want {
+		t.Fatalf("got: %v | want: %s", err, want)
+	}
+}
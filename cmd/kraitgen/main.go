@@ -0,0 +1,83 @@
+// kraitgen generates typed Go bindings from a Krait JSON spec. The generated
+// file builds the FlagSet tree with an options struct per command and calls
+// a Handlers interface with one method per command.
+//
+//	kraitgen -spec myapp.json -o cli_gen.go -package main -stubs handlers.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/runeimp/krait"
+)
+
+func main() {
+	var (
+		output  = flag.String("o", "", "Output file for the generated code (default: standard output)")
+		pkg     = flag.String("package", "main", "Package name of the generated code")
+		specArg = flag.String("spec", "", "JSON spec file to generate from")
+		stubs   = flag.String("stubs", "", "Output file for handler stubs, never overwritten")
+	)
+	flag.Parse()
+
+	if *specArg == "" && flag.NArg() == 1 {
+		*specArg = flag.Arg(0)
+	}
+	if *specArg == "" {
+		flag.Usage()
+		os.Exit(krait.ExitUsage)
+	}
+
+	if err := run(*specArg, *output, *pkg, *stubs); err != nil {
+		fmt.Fprintln(os.Stderr, "kraitgen:", err)
+		os.Exit(krait.ExitFailure)
+	}
+}
+
+func run(specPath string, output string, pkg string, stubsPath string) (err error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return err
+	}
+
+	// Validate the spec the same way krait.FromSpec would
+	if _, err = krait.FromSpec(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%s: %w", specPath, err)
+	}
+	spec, err := readSpec(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", specPath, err)
+	}
+
+	code, err := generate(spec, pkg, filepath.Base(specPath))
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = os.WriteFile(output, code, 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	if stubsPath != "" {
+		if _, err = os.Stat(stubsPath); err == nil {
+			return fmt.Errorf("%s already exists", stubsPath)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if code, err = generateStubs(spec, pkg); err != nil {
+			return err
+		}
+		err = os.WriteFile(stubsPath, code, 0644)
+	}
+
+	return err
+}
@@ -0,0 +1,133 @@
+package krait
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Exit codes used by DefaultExitCodes, following sysexits.h where it applies
+const (
+	ExitOK      = 0  // Successful termination
+	ExitFailure = 1  // Catchall for errors without a mapping
+	ExitUsage   = 64 // EX_USAGE: the command was used incorrectly
+	ExitConfig  = 78 // EX_CONFIG: something was found in an unconfigured or misconfigured state
+)
+
+// Sentinel errors wrapped by *ParseError. Use errors.Is to check for them.
+var (
+	ErrAliasConflict    = errors.New(ErrorAliasConflict)
+	ErrAliasRecursion   = errors.New(ErrorAliasRecursion)
+	ErrArgCount         = errors.New(ErrorArgumentCount)
+	ErrHelpRequested    = errors.New("help requested")
+	ErrInvalidValue     = errors.New("invalid value")
+	ErrMissingValue     = errors.New("missing value")
+	ErrNoArguments      = errors.New(ErrorNoArguments)
+	ErrOptionConflict   = errors.New("conflicting options")
+	ErrResponseFile     = errors.New(ErrorResponseFile)
+	ErrUnknownCommand   = errors.New(ErrorInvalidCommand)
+	ErrUnknownOption    = errors.New(ErrorUnknownOption)
+	ErrVersionRequested = errors.New("version requested")
+)
+
+// DefaultExitCodes is the error to exit code mapping copied into the
+// ExitCodes of every root FlagSet
+var DefaultExitCodes = map[error]int{
+	ErrAliasConflict:    ExitConfig,
+	ErrAliasRecursion:   ExitConfig,
+	ErrArgCount:         ExitUsage,
+	ErrHelpRequested:    ExitOK,
+	ErrInvalidValue:     ExitUsage,
+	ErrMissingValue:     ExitUsage,
+	ErrNoArguments:      ExitUsage,
+	ErrOptionConflict:   ExitUsage,
+	ErrResponseFile:     ExitUsage,
+	ErrUnknownCommand:   ExitUsage,
+	ErrUnknownOption:    ExitUsage,
+	ErrVersionRequested: ExitOK,
+}
+
+// ParseError describes a command line that could not be parsed. It wraps one
+// of the Err* sentinels so errors.Is and errors.As both work.
+type ParseError struct {
+	Err      error    // Sentinel such as ErrUnknownOption
+	Path     []string // Command path of the FlagSet being parsed, e.g. ["myapp", "remote"]
+	Token    string   // The offending argument, if any
+	Position int      // Index of Token in the parsed command line, -1 if not applicable
+	Message  string   // Description of the error
+}
+
+func (e *ParseError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	msg := e.Err.Error()
+	if e.Token != "" {
+		msg += " " + strconv.Quote(e.Token)
+	}
+	if len(e.Path) > 0 {
+		msg += " for " + strconv.Quote(strings.Join(e.Path, " "))
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports a ValidationError as an ErrInvalidValue
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
+
+// ExitCode returns the exit code for err from the root FlagSet ExitCodes
+// mapping. A nil error is ExitOK, a *PluginError is the plugin exit status
+// and a *ParseError is mapped by its sentinel. Other errors are mapped by the
+// first matching error in order of their messages and an unmapped error is
+// ExitFailure.
+func (fs *FlagSet) ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var perr *PluginError
+	if errors.As(err, &perr) {
+		return perr.Code
+	}
+
+	codes := fs.getRoot().ExitCodes
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		if code, ok := codes[parseErr.Err]; ok {
+			return code
+		}
+	}
+
+	sentinels := make([]error, 0, len(codes))
+	for sentinel := range codes {
+		sentinels = append(sentinels, sentinel)
+	}
+	sort.Slice(sentinels, func(i, j int) bool {
+		return sentinels[i].Error() < sentinels[j].Error()
+	})
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			return codes[sentinel]
+		}
+	}
+	return ExitFailure
+}
+
+// parseError returns a *ParseError for this FlagSet
+func (fs *FlagSet) parseError(sentinel error, token string, position int, format string, a ...any) *ParseError {
+	return &ParseError{
+		Err:      sentinel,
+		Path:     fs.getCommandList(),
+		Token:    token,
+		Position: position,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
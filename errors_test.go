@@ -0,0 +1,120 @@
+package krait
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"testing"
+)
+
+// TestParseErrors ensure parse errors wrap their sentinel and carry context
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     error
+		token    string
+		position int
+		code     int
+	}{
+		{[]string{"krait"}, ErrNoArguments, "", -1, ExitUsage},
+		{[]string{"krait", "tset"}, ErrUnknownCommand, "tset", 1, ExitUsage},
+		{[]string{"krait", "test", "--cuont", "1"}, ErrUnknownOption, "--cuont", 2, ExitUsage},
+		{[]string{"krait", "test", "one"}, ErrArgCount, "", -1, ExitUsage},
+		{[]string{"krait", "test", "-c"}, ErrMissingValue, "-c", 2, ExitUsage},
+		{[]string{"krait", "test", "-c", "x", "one", "two"}, ErrInvalidValue, "-c", 2, ExitUsage},
+		{[]string{"krait", "test", "--help", "one", "two"}, ErrHelpRequested, "--help", 2, ExitOK},
+	}
+
+	for _, tt := range tests {
+		root := NewFlagSet("krait", ContinueOnError)
+		root.DefaultSubCommand = ""
+		testFS := root.NewFlagSet("test")
+		testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+		testFS.Arg("FIRST", "First argument")
+		testFS.Arg("SECOND", "Second argument")
+
+		args := tt.args
+		_, err := root.Parse(args)
+
+		var perr *ParseError
+		if !errors.Is(err, tt.want) || !errors.As(err, &perr) {
+			t.Fatalf("%q got: %v | want: %v", args, err, tt.want)
+		}
+		if perr.Token != tt.token || perr.Position != tt.position {
+			t.Fatalf("%q got: %q at %d | want: %q at %d", args, perr.Token, perr.Position, tt.token, tt.position)
+		}
+		if code := root.ExitCode(err); code != tt.code {
+			t.Fatalf("%q got exit code: %d | want: %d", args, code, tt.code)
+		}
+	}
+}
+
+// TestExitCodes ensure the exit code mapping is configurable
+func TestExitCodes(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	root.ExitCodes[ErrUnknownCommand] = 127
+	_, err := root.Parse([]string{"krait", "tset"})
+
+	if code := root.ExitCode(err); code != 127 {
+		t.Fatalf("got: %d | want: %d", code, 127)
+	}
+	if code := root.ExitCode(errors.New("boom")); code != ExitFailure {
+		t.Fatalf("got: %d | want: %d", code, ExitFailure)
+	}
+	if code := root.ExitCode(&ValidationError{Err: errors.New("too big")}); code != ExitUsage {
+		t.Fatalf("got: %d | want: %d", code, ExitUsage)
+	}
+
+	// An error matching several mapped errors always gets the same exit code
+	errBusy := errors.New("busy")
+	root.ExitCodes[errBusy] = 75
+	root.ExitCodes[ErrInvalidValue] = 65
+	wrapped := &ValidationError{Err: errBusy}
+	for i := 0; i < 20; i++ {
+		if code := root.ExitCode(wrapped); code != 75 {
+			t.Fatalf("got: %d | want: %d", code, 75)
+		}
+		if code := root.ExitCode(root.parseError(wrapped, "", -1, "busy")); code != 75 {
+			t.Fatalf("got: %d | want: %d", code, 75)
+		}
+	}
+}
+
+// TestErrorHandling ensure the ErrorHandling mode is inherited from the root
+// and applied to built-in commands
+func TestErrorHandling(t *testing.T) {
+	var buf bytes.Buffer
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+
+	root := NewFlagSet("krait", ContinueOnError)
+	root.NewFlagSet("test")
+	panicFS := root.NewFlagSet("panic", PanicOnError)
+	panicFS.NArgs = 0
+
+	if _, err := root.Parse([]string{"krait", "version"}); !errors.Is(err, ErrVersionRequested) {
+		t.Fatalf("version got: %v | want: %v", err, ErrVersionRequested)
+	}
+	if got := root.subcommands["test"].ErrorHandling(); got != ContinueOnError {
+		t.Fatalf("inherited got: %v | want: %v", got, ContinueOnError)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("PanicOnError subcommand did not panic")
+			}
+		}()
+		root.Parse([]string{"krait", "panic", "extra"})
+	}()
+
+	exitCode := -1
+	root = NewFlagSet("krait")
+	root.Exit = func(code int) { exitCode = code }
+	if _, err := root.Parse([]string{"krait", "help"}); !errors.Is(err, ErrHelpRequested) || exitCode != ExitOK {
+		t.Fatalf("help got: %v with exit code %d | want: %v with exit code %d", err, exitCode, ErrHelpRequested, ExitOK)
+	}
+	if _, err := root.Parse([]string{"krait", "tset"}); !errors.Is(err, ErrUnknownCommand) || exitCode != ExitUsage {
+		t.Fatalf("unknown command got: %v with exit code %d | want: %v with exit code %d", err, exitCode, ErrUnknownCommand, ExitUsage)
+	}
+}
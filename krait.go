@@ -9,11 +9,13 @@ package krait
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"math"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -25,18 +27,32 @@ const (
 )
 
 const (
-	ContinueOnError     flag.ErrorHandling = flag.ContinueOnError
-	ExitOnError         flag.ErrorHandling = flag.ExitOnError
-	PanicOnError        flag.ErrorHandling = flag.PanicOnError
-	ErrorInvalidCommand                    = "invalid command"
-	ErrorNoArguments                       = "no command line arguments"
-	OptionBool                             = "bool"
-	OptionFloat                            = "float64"
-	OptionInt                              = "int"
-	OptionString                           = "string"
-	OptionUint                             = "uint"
-	summeryTitle                           = "COMMAND SUMMERY\n---------------" // May become editable in future versions
-	optionTitle                            = "\n\nOPTIONS\n-------"             // May become editable in future versions
+	ContinueOnError        flag.ErrorHandling = flag.ContinueOnError
+	ExitOnError            flag.ErrorHandling = flag.ExitOnError
+	PanicOnError           flag.ErrorHandling = flag.PanicOnError
+	ErrorAliasConflict                        = "alias conflicts with a command"
+	ErrorAliasRecursion                       = "recursive alias"
+	ErrorArgumentCount                        = "wrong number of arguments"
+	ErrorInvalidArgument                      = "invalid argument"
+	ErrorInvalidCommand                       = "invalid command"
+	ErrorMutuallyExclusive                    = "mutually exclusive options"
+	ErrorNoArguments                          = "no command line arguments"
+	ErrorRequiredOption                       = "missing required option"
+	ErrorRequiredTogether                     = "options must be used together"
+	ErrorResponseFile                         = "invalid response file"
+	ErrorUnknownOption                        = "unknown option"
+	OptionBool                                = "bool"
+	OptionFloat                               = "float64"
+	OptionInt                                 = "int"
+	OptionMap                                 = "map"
+	OptionString                              = "string"
+	OptionUint                                = "uint"
+	OptionValue                               = "value"
+	summeryTitle                              = "COMMAND SUMMERY\n---------------" // May become editable in future versions
+	optionTitle                               = "\n\nOPTIONS\n-------"             // May become editable in future versions
+	argumentTitle                             = "\nARGUMENTS\n---------"           // May become editable in future versions
+	groupTitle                                = "\nOPTION GROUPS\n-------------"   // May become editable in future versions
+	aliasTitle                                = "\nUSER ALIASES\n------------"     // May become editable in future versions
 	// ErrorInvalidSubCommand                    = "invalid subcommand"
 
 // 	appUsage = `
@@ -53,15 +69,22 @@ const (
 
 const (
 	ArgBareArgument   ArgumentType = "bare-argument"
+	ArgEndOfOptions   ArgumentType = "end-of-options"
 	ArgOptionArgument ArgumentType = "option-argument"
 	ArgOptionAlias    ArgumentType = "option-alias"
 	ArgSubCommand     ArgumentType = "sub-command"
 )
 
-const usageNoOptions = `Usage: %s [ARGUMENTS]
+const (
+	arityNone     optionArity = iota // Binary flag that never takes an option-argument
+	arityRequired                    // Option-argument follows the option or is attached with =
+	arityOptional                    // Option-argument may only be attached with =
+)
+
+const usageNoOptions = `Usage: %s%s
 `
 
-const usageWithOptions = `Usage: %s [OPTIONS] [ARGUMENTS]
+const usageWithOptions = `Usage: %s [OPTIONS]%s
 
 OPTIONS
 -------
@@ -69,36 +92,99 @@ OPTIONS
 
 type ArgumentType string
 
+// optionArity describes if and how an option takes an option-argument
+type optionArity int
+
+// optionGroupType is the kind of constraint an optionGroup applies
+type optionGroupType int
+
+const (
+	groupMutuallyExclusive optionGroupType = iota // At most one option may be set
+	groupOneRequired                              // At least one option must be set
+	groupRequiredTogether                         // All or none of the options must be set
+)
+
+// optionGroup is a constraint on a set of options checked after parsing
+type optionGroup struct {
+	kind  optionGroupType
+	names []string // Canonical option names
+}
+
 type Argument struct {
-	Type  ArgumentType
-	Value string
+	Type     ArgumentType
+	Value    string
+	Position int // Index of the command line argument the token came from
+}
+
+// argSpec describes a named positional argument
+type argSpec struct {
+	max   int                          // Maximum number of values, -1 = unlimited
+	min   int                          // Minimum number of values
+	name  string                       // Name shown in help such as SOURCE or FILES
+	set   func(values ...string) error // Converts and stores the values
+	usage string                       // Description shown in help
+	value any                          // Pointer returned by the Arg method, zeroed by Reset
 }
 
 // FlagSet is the Krait expansion of flag.FlagSet
 type FlagSet struct {
+	AllowExperimental bool                                 // Enables experimental subcommands and options, e.g. from a config file. Only used on the root FlagSet.
 	AppLabel          string                               // Application name and version number
 	args              []string                             // bare arguments
-	NArgs             int                                  // The number of arguments expected for this subcommand. 0 = none, 1+ = the exact number of expected arguments, -1 = any number of arguments
+	bindings          []func()                             // Copy the parsed values into structs registered with Bind
+	argSpecs          []argSpec                            // Named positional arguments
+	NArgs             int                                  // The number of arguments expected for this subcommand. 0 = none, 1+ = the exact number of expected arguments, -1 = any number of arguments (default). Ignored if named arguments are defined.
 	cmd               string                               // Command name
+	config            map[string]configValue               // Option values from config files set with ApplyConfig
 	CmdFunc           func(fs *FlagSet, args ...string)    // Function to call when the command is active
 	DefaultSubCommand string                               // The subcommand to use when none is specified
+	Deprecated        string                               // Warning reported when the subcommand is used, such as "use \"myapp remote add\" instead". Empty if not deprecated.
 	Epilogue          string                               // Help epilogue
+	errorHandling     *flag.ErrorHandling                  // How parse errors are handled. nil inherits from the parent FlagSet.
+	Exit              func(code int)                       // Called to exit under ExitOnError, os.Exit by default. Only used on the root FlagSet.
+	ExitCodes         map[error]int                        // Error to exit code mapping used by ExitCode. Only used on the root FlagSet.
+	Experimental      bool                                 // The subcommand is unknown unless experimental features are enabled
+	ExperimentalEnv   string                               // Environment variable that enables experimental subcommands and options when set to a true value. Only used on the root FlagSet.
+	experimentalOn    bool                                 // If the experimental option was given on this command line. Only used on the root FlagSet.
+	experimentalOpt   string                               // Name of the root option that enables experimental subcommands and options, empty if none. Only used on the root FlagSet.
+	exitErr           error                                // Error reported by a built-in command such as help during Parse
 	flagSet           *flag.FlagSet                        // flag.FlagSet for the krait.FlagSet
 	HelpOutput        func(fs *FlagSet, cmdName ...string) // The default help output method
+	Hidden            bool                                 // The subcommand is omitted from help and completion but still parsed
 	isParsed          bool                                 // If a command line was parsed yet
 	level             int                                  // Sub command level
 	optionAliases     map[string]string                    // POSIX or GNU aliases for an option
+	optionGroups      []optionGroup                        // Constraints on sets of options such as mutually exclusive options
 	Options           map[string]Option                    // Map of options to track
 	parent            *FlagSet                             // Parent krait.FlagSet of this krait.FlagSet
+	ResponseFiles     ResponseFileFormat                   // Format of @file arguments to expand, ResponseFilesOff by default. Only used on the root FlagSet.
+	pluginDirs        []string                             // Dirs searched for external plugins before $PATH
+	pluginsEnabled    bool                                 // If unregistered subcommands may run external plugins
 	subcmd            string                               // Active sub-command
-	subcmdAliases     []map[string]string                  // Slice of Map string of valid sub-command aliases. NOTE: really should be []map[string][]string (level / subcommand / aliases)
-	subcommands       []map[string]*FlagSet                // Slice of Map of krait.FlagSet sub-commands. The slice represents the subcommand levels
+	subcmdAliases     map[string]string                    // Aliases of the subcommands of this FlagSet and the subcommand names they stand for
+	subcommands       map[string]*FlagSet                  // Subcommands of this FlagSet by name
+	userAliases       map[string]string                    // User defined aliases of the subcommands of this FlagSet and their expansions
 	Summery           string                               // krait.FlagSet sub-command usage summery
+	WarnDeprecated    func(name string, message string)    // Reports a deprecated subcommand or option that was used, printing "warning: <name> is deprecated: <message>" to stderr by default. Only used on the root FlagSet.
 	// Root          bool
 	// Usage         func()
 	// usageTemplate string
 }
 
+// acceptsBareArgs reports if bare arguments are allowed following this
+// FlagSet. Per doc/logic.md the root never takes bare arguments and a
+// subcommand with subcommands of its own only does if it expects them.
+func (fs *FlagSet) acceptsBareArgs() bool {
+	if fs.parent == nil {
+		return false
+	}
+	if len(fs.subcommandNames()) == 0 {
+		return true
+	}
+	_, max := fs.argsRange()
+	return max != 0
+}
+
 func (fs *FlagSet) String() string {
 	dict := make(map[string]string)
 	dict["AppLabel"] = fs.AppLabel
@@ -124,46 +210,331 @@ func (fs *FlagSet) String() string {
 	return result
 }
 
-func (fs *FlagSet) argIsSubcommand(arg string, level int) (subcmd string, result bool) {
-	arg = strings.ToLower(arg) // Lowercase because subcommand case shouldn't matter
-	// log.Printf("krait.FlagSet.argIsSubcommand() | %q | level: %d | arg: %q\n", fs.cmd, level, arg)
-
-	rfs := fs.getRoot()
+// applyChecks runs the validators and transformers of every option set in the
+// active command chain. Checks run once all value layers, the command line,
+// environment variables and config files, have been merged and only on values that were
+// explicitly set, never on defaults.
+func (fs *FlagSet) applyChecks() (err error) {
+	for _, cfs := range fs.getCommandChain() {
+		names := []string{}
+		for name, o := range cfs.Options {
+			if len(o.checks) > 0 && cfs.optionIsSet(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			f := cfs.flagSet.Lookup(name)
+			original := f.Value.String()
+			value := original
+
+			for _, check := range cfs.Options[name].checks {
+				if value, err = check(value); err != nil {
+					return &ValidationError{
+						Option: cfs.optionDisplayName(name),
+						Source: cfs.Options[name].Source().String(),
+						Value:  original,
+						Err:    err,
+					}
+				}
+			}
 
-	// log.Printf("krait.FlagSet.argIsSubcommand() | %q | level: %d | len(rfs.subcommands): %d\n", fs.cmd, level, len(rfs.subcommands))
-	// Check subcommands for a match
-	if level < len(rfs.subcommands) {
-		for k := range rfs.subcommands[level] {
-			if arg == k {
-				subcmd = arg
-				result = true
-				break
+			if value != original {
+				// An accumulating value would add the transformed value to the original
+				if rv, ok := f.Value.(ResettableValue); ok {
+					rv.Reset()
+				}
+				if err = f.Value.Set(value); err != nil {
+					return &ValidationError{Option: cfs.optionDisplayName(name), Source: cfs.Options[name].Source().String(), Value: value, Err: err}
+				}
 			}
 		}
 	}
 
-	// log.Printf("krait.FlagSet.argIsSubcommand() | %q | level: %d | len(rfs.subcmdAliases): %d | result: %t\n", fs.cmd, level, len(rfs.subcmdAliases), result)
-	if result == false && level < len(rfs.subcmdAliases) {
-		// Check subcommand aliases for a match
-		for alias, sub := range rfs.subcmdAliases[level] {
-			// log.Printf("krait.FlagSet.argIsSubcommand() | %q | level: %d | arg: %q | alias: %q | sub: %q\n", fs.cmd, level, arg, alias, sub)
-			if arg == alias {
-				subcmd = sub
-				result = true
-				break
+	return err
+}
+
+// applyEnv sets the options of the active command chain that were not set
+// on the command line from their environment variables
+func (fs *FlagSet) applyEnv() (err error) {
+	for _, cfs := range fs.getCommandChain() {
+		names := []string{}
+		for name, o := range cfs.Options {
+			if o.env != "" && !cfs.optionIsSet(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			value, ok := os.LookupEnv(cfs.Options[name].env)
+			if !ok {
+				continue
+			}
+			source := Source{Kind: SourceEnv, Name: cfs.Options[name].env, Position: -1}
+			if err = cfs.optionSet(name, value, source); err != nil {
+				return &ValidationError{Option: cfs.optionDisplayName(name), Source: source.String(), Value: value, Err: err}
 			}
 		}
 	}
 
-	// log.Printf("krait.FlagSet.argIsSubcommand() | %q | level: %d | arg: %q | subcmd: %q | result: %t\n", fs.cmd, level, arg, subcmd, result)
+	return err
+}
+
+// Arg defines a named positional string argument. Named arguments are
+// assigned in the order they are defined and replace NArgs checking.
+func (fs *FlagSet) Arg(name string, description string) (a *string) {
+	a = new(string)
+	fs.argSpecAdd(name, 1, 1, description, a, func(values ...string) error {
+		*a = values[0]
+		return nil
+	})
+	return a
+}
+
+// ArgFloat defines a named positional float64 argument
+func (fs *FlagSet) ArgFloat(name string, description string) (a *float64) {
+	a = new(float64)
+	fs.argSpecAdd(name, 1, 1, description, a, func(values ...string) (err error) {
+		*a, err = strconv.ParseFloat(values[0], 64)
+		return err
+	})
+	return a
+}
+
+// ArgInt defines a named positional int argument
+func (fs *FlagSet) ArgInt(name string, description string) (a *int) {
+	a = new(int)
+	fs.argSpecAdd(name, 1, 1, description, a, func(values ...string) (err error) {
+		*a, err = strconv.Atoi(values[0])
+		return err
+	})
+	return a
+}
+
+// argIsSubcommand returns the name of the subcommand of this FlagSet that
+// arg names or is an alias of
+func (fs *FlagSet) argIsSubcommand(arg string) (subcmd string, result bool) {
+	arg = strings.ToLower(arg) // Lowercase because subcommand case shouldn't matter
+
+	if _, result = fs.subcommands[arg]; result {
+		return arg, result
+	}
+	subcmd, result = fs.subcmdAliases[arg]
 
 	return subcmd, result
 }
 
+// ArgList defines a variadic named positional argument taking between min
+// and max values, or any number over min if max is -1. It must be the last
+// named argument defined, and panics if min is negative or above a max other
+// than -1. (Args is already the accessor for the parsed bare arguments.)
+func (fs *FlagSet) ArgList(name string, min int, max int, description string) (a *[]string) {
+	if min < 0 || (max != -1 && min > max) {
+		panic(fmt.Sprintf("argument %s has an invalid range of %d to %d values", name, min, max))
+	}
+	a = new([]string)
+	fs.argSpecAdd(name, min, max, description, a, func(values ...string) error {
+		*a = append([]string{}, values...)
+		return nil
+	})
+	return a
+}
+
 func (fs *FlagSet) Args() []string {
 	return fs.args
 }
 
+// argSpecAdd registers a named positional argument
+func (fs *FlagSet) argSpecAdd(name string, min int, max int, description string, value any, set func(values ...string) error) {
+	if n := len(fs.argSpecs); n > 0 && fs.argSpecs[n-1].min != fs.argSpecs[n-1].max {
+		panic(fmt.Sprintf("argument %s defined after the variadic argument %s", name, fs.argSpecs[n-1].name))
+	}
+	fs.argSpecs = append(fs.argSpecs, argSpec{max: max, min: min, name: name, set: set, usage: description, value: value})
+}
+
+// ArgUint defines a named positional uint argument
+func (fs *FlagSet) ArgUint(name string, description string) (a *uint) {
+	a = new(uint)
+	fs.argSpecAdd(name, 1, 1, description, a, func(values ...string) error {
+		v, err := strconv.ParseUint(values[0], 10, 0)
+		*a = uint(v)
+		return err
+	})
+	return a
+}
+
+// argsRange returns the minimum and maximum number of bare arguments
+// accepted. A max of -1 means there is no limit.
+func (fs *FlagSet) argsRange() (min int, max int) {
+	if len(fs.argSpecs) == 0 {
+		if fs.NArgs < 0 {
+			return 0, -1
+		}
+		return fs.NArgs, fs.NArgs
+	}
+
+	for _, spec := range fs.argSpecs {
+		min += spec.min
+		if max >= 0 {
+			if spec.max < 0 {
+				max = -1
+			} else {
+				max += spec.max
+			}
+		}
+	}
+	return min, max
+}
+
+// argumentsUsage returns the arguments portion of the usage line
+func (fs *FlagSet) argumentsUsage() string {
+	if len(fs.argSpecs) == 0 {
+		switch fs.NArgs {
+		case 0:
+			return ""
+		case 1:
+			return "ARGUMENT"
+		default:
+			return "[ARGUMENTS]"
+		}
+	}
+
+	names := []string{}
+	for _, spec := range fs.argSpecs {
+		switch {
+		case spec.min == 1 && spec.max == 1:
+			names = append(names, spec.name)
+		case spec.min == 0:
+			names = append(names, "["+spec.name+"...]")
+		default:
+			names = append(names, spec.name+"...")
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// checkGroups returns a single error describing every option group
+// constraint in the active command chain that was violated. Options count
+// once they are set on the command line, by their environment variable or
+// from a config file, whatever their values.
+func (fs *FlagSet) checkGroups() (err error) {
+	sentinels := []error{}
+	violations := []string{}
+
+	for _, cfs := range fs.getCommandChain() {
+		for _, group := range cfs.optionGroups {
+			set := []string{}
+			unset := []string{}
+			for _, name := range group.names {
+				if cfs.optionIsSet(name) {
+					set = append(set, cfs.optionDisplayName(name))
+				} else {
+					unset = append(unset, cfs.optionDisplayName(name))
+				}
+			}
+
+			switch {
+			case group.kind == groupMutuallyExclusive && len(set) > 1:
+				violations = append(violations, fmt.Sprintf("%s: %s", ErrorMutuallyExclusive, strings.Join(set, ", ")))
+				sentinels = append(sentinels, ErrOptionConflict)
+			case group.kind == groupOneRequired && len(set) == 0:
+				violations = append(violations, fmt.Sprintf("%s: one of %s", ErrorRequiredOption, strings.Join(unset, ", ")))
+				sentinels = append(sentinels, ErrMissingValue)
+			case group.kind == groupRequiredTogether && len(set) > 0 && len(unset) > 0:
+				violations = append(violations, fmt.Sprintf("%s: %s set without %s", ErrorRequiredTogether, strings.Join(set, ", "), strings.Join(unset, ", ")))
+				sentinels = append(sentinels, ErrOptionConflict)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		err = fs.parseError(sentinels[0], "", -1, "%s", strings.Join(violations, "; "))
+	}
+
+	return err
+}
+
+// checkRequired returns a single error listing every required option in the
+// active command chain that was not set
+func (fs *FlagSet) checkRequired() (err error) {
+	missing := []string{}
+
+	for _, cfs := range fs.getCommandChain() {
+		names := []string{}
+		for name, o := range cfs.Options {
+			if o.required && !cfs.optionIsSet(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			missing = append(missing, cfs.optionDisplayName(name))
+		}
+	}
+
+	switch len(missing) {
+	case 0:
+	case 1:
+		err = fs.parseError(ErrMissingValue, "", -1, "%s: %s", ErrorRequiredOption, missing[0])
+	default:
+		err = fs.parseError(ErrMissingValue, "", -1, "%ss: %s", ErrorRequiredOption, strings.Join(missing, ", "))
+	}
+
+	return err
+}
+
+// Env sets the environment variable used for the named option when it is
+// not set on the command line. Any alias may be used to name the option.
+func (fs *FlagSet) Env(name string, variable string) {
+	canonical, ok := fs.optionName(name)
+	if !ok {
+		panic(fmt.Sprintf("cannot set the environment variable of undefined option %q", name))
+	}
+	o := fs.Options[canonical]
+	o.env = variable
+	fs.Options[canonical] = o
+}
+
+// ErrorHandling returns the error handling behavior of the FlagSet. A
+// subcommand inherits the mode of its parent unless one was passed to
+// NewFlagSet.
+func (fs *FlagSet) ErrorHandling() flag.ErrorHandling {
+	for f := fs; f != nil; f = f.parent {
+		if f.errorHandling != nil {
+			return *f.errorHandling
+		}
+	}
+	return ExitOnError
+}
+
+// getCommandChain returns the FlagSets from the root down to this FlagSet
+func (fs *FlagSet) getCommandChain() (chain []*FlagSet) {
+	for p := fs; p != nil; p = p.parent {
+		chain = append([]*FlagSet{p}, chain...)
+	}
+	return chain
+}
+
+// getCommandTree returns this FlagSet followed by all of its subcommands,
+// depth first in name order
+func (fs *FlagSet) getCommandTree() (tree []*FlagSet) {
+	tree = append(tree, fs)
+
+	names := make([]string, 0, len(fs.subcommands))
+	for name := range fs.subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		tree = append(tree, fs.subcommands[name].getCommandTree()...)
+	}
+	return tree
+}
+
 func (fs *FlagSet) getCommandList() (list []string) {
 	list = append(list, fs.cmd)
 	parent := fs.parent
@@ -178,12 +549,9 @@ func (fs *FlagSet) getCommandList() (list []string) {
 	return list
 }
 
-func (fs *FlagSet) getDefaultSubCommand(level int, subcmd string) (result string) {
-	rfs := fs.getRoot()
-	if level < len(rfs.subcommands) {
-		if _, ok := rfs.subcommands[level][subcmd]; ok {
-			result = rfs.subcommands[level][subcmd].DefaultSubCommand
-		}
+func (fs *FlagSet) getDefaultSubCommand(subcmd string) (result string) {
+	if sub, ok := fs.subcommands[subcmd]; ok {
+		result = sub.DefaultSubCommand
 	}
 	return result
 }
@@ -201,11 +569,11 @@ func (fs *FlagSet) getRoot() (p *FlagSet) {
 	return p
 }
 
-func (fs *FlagSet) getSubCommandAliases(subcommand string) (result []string) {
-	rfs := fs.getRoot()
-	if rfs.subcmdAliases[fs.level] != nil {
-		for alias, subcmd := range rfs.subcmdAliases[fs.level] {
-			if subcmd == subcommand {
+// getSubCommandAliases returns the sorted aliases of this subcommand
+func (fs *FlagSet) getSubCommandAliases() (result []string) {
+	if fs.parent != nil {
+		for alias, subcmd := range fs.parent.subcmdAliases {
+			if subcmd == fs.cmd {
 				result = append(result, alias)
 			}
 		}
@@ -214,24 +582,62 @@ func (fs *FlagSet) getSubCommandAliases(subcommand string) (result []string) {
 	return result
 }
 
-func (fs *FlagSet) NewFlagSet(subcommand string, errHandler ...flag.ErrorHandling) *FlagSet {
-	// log.Printf("krait.FlagSet.NewFlagSet() | %q | subcommand: %q\n", fs.cmd, subcommand)
-	errorHandler := flag.ExitOnError
-	if len(errHandler) > 0 {
-		errorHandler = errHandler[0]
+// handleError applies the ErrorHandling of the FlagSet to a parse error the
+// same way flag.FlagSet does, printing the error and subcommand usage before
+// returning, exiting or panicking
+func (fs *FlagSet) handleError(err error) error {
+	if !errors.Is(err, ErrHelpRequested) {
+		fmt.Fprintln(fs.flagSet.Output(), err)
+	}
+	if fs.parent != nil {
+		fs.flagSet.Usage()
+	}
+
+	return fs.terminate(err)
+}
+
+// lookupOption resolves any POSIX, Multics or GNU form of an option alias to
+// the canonical prefixed option name
+func (fs *FlagSet) lookupOption(name string) (canonical string, ok bool) {
+	if canonical, ok = fs.optionAliases[name]; ok {
+		return canonical, ok
+	}
+
+	// The canonical name itself may be used with either prefix
+	if strings.HasPrefix(name, "-") && !strings.HasPrefix(name, "---") {
+		canonical, ok = fs.optionAliases["--"+strings.TrimLeft(name, "-")]
 	}
 
+	return canonical, ok
+}
+
+// MutuallyExclusive declares that at most one of the named options may be
+// set. Any alias may be used to name an option.
+func (fs *FlagSet) MutuallyExclusive(names ...string) {
+	fs.optionGroupAdd(groupMutuallyExclusive, names)
+}
+
+// NewFlagSet defines a subcommand of the FlagSet. The subcommand inherits
+// the ErrorHandling of its parent unless errHandler is given. Subcommand
+// names and aliases must be unique among the subcommands of a FlagSet,
+// NewFlagSet panics if the name is already used.
+func (fs *FlagSet) NewFlagSet(subcommand string, errHandler ...flag.ErrorHandling) *FlagSet {
+	// log.Printf("krait.FlagSet.NewFlagSet() | %q | subcommand: %q\n", fs.cmd, subcommand)
 	subcommand = strings.ToLower(subcommand) // Lowercase because case shouldn't matter
 
 	nfs := &FlagSet{
 		cmd:           subcommand,
-		flagSet:       flag.NewFlagSet(subcommand, errorHandler),
+		flagSet:       flag.NewFlagSet(subcommand, flag.ContinueOnError), // Errors are handled by Krait
 		level:         fs.level + 1,
+		NArgs:         -1,
 		parent:        fs,
 		Options:       make(map[string]Option),
 		optionAliases: make(map[string]string),
-		subcmdAliases: make([]map[string]string, 2),
-		subcommands:   make([]map[string]*FlagSet, 2),
+		subcmdAliases: make(map[string]string),
+		subcommands:   make(map[string]*FlagSet),
+	}
+	if len(errHandler) > 0 {
+		nfs.errorHandling = &errHandler[0]
 	}
 
 	// nfs.flagSet.Usage = func() {
@@ -242,17 +648,22 @@ func (fs *FlagSet) NewFlagSet(subcommand string, errHandler ...flag.ErrorHandlin
 		usage := usageNoOptions
 		longestName := 0
 		formatNoDefault := "  %%-%ds  %%s (no default)\n"
+		formatRequired := "  %%-%ds  %%s (required)\n"
 		formatWithDefault := "  %%-%ds  %%s (default: %%v)\n"
 
 		nfs.flagSet.VisitAll(func(f *flag.Flag) {
-			if len(f.Name) > longestName {
-				longestName = len(f.Name)
+			if !nfs.optionVisible(f.Name) {
+				return
+			}
+			if len(nfs.optionUsageName(f)) > longestName {
+				longestName = len(nfs.optionUsageName(f))
 			}
 			optionCount++
 		})
 
-		longestName += 3
+		longestName += 2
 		formatNoDefault = fmt.Sprintf(formatNoDefault, longestName)
+		formatRequired = fmt.Sprintf(formatRequired, longestName)
 		formatWithDefault = fmt.Sprintf(formatWithDefault, longestName)
 
 		if optionCount > 0 {
@@ -260,11 +671,15 @@ func (fs *FlagSet) NewFlagSet(subcommand string, errHandler ...flag.ErrorHandlin
 		}
 
 		cmdChain := strings.Join(nfs.getCommandList(), " ")
+		argsUsage := nfs.argumentsUsage()
+		if argsUsage != "" {
+			argsUsage = " " + argsUsage
+		}
 
 		fmt.Fprintln(flag.CommandLine.Output(), fs.AppLabel)
 		fmt.Fprintln(flag.CommandLine.Output())
 		// fmt.Fprintf(flag.CommandLine.Output(), usage, nfs.parent.cmd, nfs.cmd)
-		fmt.Fprintf(flag.CommandLine.Output(), usage, cmdChain)
+		fmt.Fprintf(flag.CommandLine.Output(), usage, cmdChain, argsUsage)
 
 		// log.Printf("FlagSet.flagSet.Usage() | nfs.optionAliases: %#v\n", nfs.optionAliases)
 		// for k, v := range nfs.optionAliases {
@@ -272,8 +687,12 @@ func (fs *FlagSet) NewFlagSet(subcommand string, errHandler ...flag.ErrorHandlin
 		// }
 
 		nfs.flagSet.VisitAll(func(f *flag.Flag) {
-			optionName := fmt.Sprintf("-%s", f.Name)
+			if !nfs.optionVisible(f.Name) {
+				return
+			}
+			optionName := nfs.optionUsageName(f)
 			gnuOptionName := fmt.Sprintf("--%s", f.Name)
+			_, optionUsage := flag.UnquoteUsage(f)
 
 			aliases := []string{}
 			for k, v := range nfs.optionAliases {
@@ -288,12 +707,14 @@ func (fs *FlagSet) NewFlagSet(subcommand string, errHandler ...flag.ErrorHandlin
 				optionName += ", " + aliases[0]
 			}
 
-			if f.DefValue == "" {
+			if nfs.Options[f.Name].required {
+				fmt.Fprintf(flag.CommandLine.Output(), formatRequired, optionName, optionUsage)
+			} else if f.DefValue == "" {
 				// fmt.Fprintf(flag.CommandLine.Output(), "  %-13s  %s (no default)\n", optionName, f.Usage)
-				fmt.Fprintf(flag.CommandLine.Output(), formatNoDefault, optionName, f.Usage)
+				fmt.Fprintf(flag.CommandLine.Output(), formatNoDefault, optionName, optionUsage)
 			} else {
 				// fmt.Fprintf(flag.CommandLine.Output(), "  %-13s  %s (default: %v)\n", optionName, f.Usage, f.DefValue)
-				fmt.Fprintf(flag.CommandLine.Output(), formatWithDefault, optionName, f.Usage, f.DefValue)
+				fmt.Fprintf(flag.CommandLine.Output(), formatWithDefault, optionName, optionUsage, f.DefValue)
 			}
 			if len(aliases) > 0 {
 				// fmt.Fprintf(flag.CommandLine.Output(), "    %v\n", aliases)
@@ -307,24 +728,87 @@ func (fs *FlagSet) NewFlagSet(subcommand string, errHandler ...flag.ErrorHandlin
 				}
 			}
 		})
+
+		if len(nfs.optionGroups) > 0 {
+			fmt.Fprintln(flag.CommandLine.Output(), groupTitle)
+			for _, group := range nfs.optionGroups {
+				fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", nfs.optionGroupUsage(group))
+			}
+		}
+
+		if len(nfs.argSpecs) > 0 {
+			longestName = 0
+			for _, spec := range nfs.argSpecs {
+				if len(spec.name) > longestName {
+					longestName = len(spec.name)
+				}
+			}
+			format := fmt.Sprintf("  %%-%ds  %%s\n", longestName+2)
+
+			fmt.Fprintln(flag.CommandLine.Output(), argumentTitle)
+			for _, spec := range nfs.argSpecs {
+				fmt.Fprintf(flag.CommandLine.Output(), format, spec.name, spec.usage)
+			}
+		}
 		fmt.Println()
 	}
 
-	if fs.subcommands[nfs.level] == nil {
-		fs.subcommands[nfs.level] = make(map[string]*FlagSet)
+	// Subcommands are registered with their parent so subcommands of
+	// different parents may share a name
+	if owner, ok := fs.commandOwner(subcommand); ok {
+		panic(fmt.Sprintf("cannot define subcommand %q for %q: already used by %q", subcommand, strings.Join(fs.getCommandList(), " "), strings.Join(owner.getCommandList(), " ")))
 	}
-	fs.subcommands[nfs.level][subcommand] = nfs
+	fs.subcommands[subcommand] = nfs
 
 	return nfs
 }
 
+// Option returns the option named by any of its aliases, with or without
+// its dash prefix, e.g. fs.Option("c"), fs.Option("--count")
+func (fs *FlagSet) Option(name string) (o Option, ok bool) {
+	if name, ok = fs.optionName(name); ok {
+		o, ok = fs.Options[name]
+	}
+	return o, ok
+}
+
+// optionAdd records the Option defined by the flag with the canonical alias
+func (fs *FlagSet) optionAdd(alias string, aliases []string, o Option) {
+	o.aliases = append([]string{alias}, aliases...)
+	o.flag = fs.flagSet.Lookup(alias)
+	o.state = new(optionState)
+	fs.Options[alias] = o
+}
+
+// optionAliasList returns the sorted prefixed aliases of an option, not
+// including its canonical name
+func (fs *FlagSet) optionAliasList(name string) (aliases []string) {
+	canonical := fs.optionAliases["--"+name]
+	for alias, v := range fs.optionAliases {
+		if v == canonical && alias != canonical && alias != "--"+name {
+			aliases = append(aliases, alias)
+		}
+	}
+	aliasSort(aliases)
+	return aliases
+}
+
 func (fs *FlagSet) optionAliasSetup(aliasList []string) (alias string, aliases []string) {
 	var (
 		aliasPrefixed string
 		longest       int
 	)
 
+	seen := map[string]bool{}
 	for i, arg := range aliasList {
+		if canonical, ok := fs.optionAliasConflict(arg); ok {
+			panic(fmt.Sprintf("cannot define option alias %q for %q: already used by %s", arg, strings.Join(fs.getCommandList(), " "), canonical))
+		}
+		if seen[arg] {
+			panic(fmt.Sprintf("cannot define option alias %q for %q twice", arg, strings.Join(fs.getCommandList(), " ")))
+		}
+		seen[arg] = true
+
 		if len(arg) >= len(aliasList[longest]) {
 			longest = i
 		}
@@ -364,28 +848,106 @@ func (fs *FlagSet) optionAliasSetup(aliasList []string) (alias string, aliases [
 	return alias, aliases
 }
 
-func (fs *FlagSet) OptionBool(aliases []string, defaultValue bool, description string) (o *bool) {
+// optionArity reports how the option with the canonical prefixed name takes
+// its option-argument
+func (fs *FlagSet) optionArity(canonical string) optionArity {
+	f := fs.flagSet.Lookup(strings.TrimLeft(canonical, "-"))
+	if f == nil {
+		return arityNone
+	}
+	if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+		return arityNone
+	}
+	if fs.Options[f.Name].optional {
+		return arityOptional
+	}
+	return arityRequired
+}
+
+// optionDisplayName returns the canonical prefixed option name followed by
+// its aliases for use in error messages, e.g. --count (-c)
+func (fs *FlagSet) optionDisplayName(name string) (display string) {
+	display = fs.optionAliases["--"+name]
+	if aliases := fs.optionAliasList(name); len(aliases) > 0 {
+		display += " (" + strings.Join(aliases, ", ") + ")"
+	}
+	return display
+}
+
+func (fs *FlagSet) OptionBool(aliases []string, defaultValue bool, description string, checks ...OptionCheck) (o *bool) {
 	var alias string
 	alias, aliases = fs.optionAliasSetup(aliases)
 	o = fs.flagSet.Bool(alias, defaultValue, description)
-	fs.Options[alias] = Option{
-		Type:  OptionBool,
-		value: o,
-	}
+	fs.optionAdd(alias, aliases, Option{
+		Type:      OptionBool,
+		checks:    checks,
+		negatable: fs.optionNegationSetup(alias, aliases),
+		value:     o,
+	})
 	return o
 }
 
-func (fs *FlagSet) OptionFloat(aliases []string, defaultValue float64, description string) (o *float64) {
+// optionNegationSetup registers a --no-<name> alias for every GNU long name
+// of a boolean option and returns true if at least one was added
+func (fs *FlagSet) optionNegationSetup(alias string, aliases []string) (negatable bool) {
+	canonical := fs.optionAliases["--"+alias]
+
+	for _, a := range append([]string{alias}, aliases...) {
+		if len(a) < 2 {
+			continue // POSIX short options have no negated form
+		}
+		negation := "--no-" + a
+		if _, ok := fs.optionAliases[negation]; ok {
+			continue // Never hijack an existing option
+		}
+		fs.optionAliases[negation] = canonical + "=false"
+		negatable = true
+	}
+
+	return negatable
+}
+
+func (fs *FlagSet) OptionFloat(aliases []string, defaultValue float64, description string, checks ...OptionCheck) (o *float64) {
 	var alias string
 
 	// log.Printf("krait.FlagSet.OptionFloat() | %q | aliases: %q\n", fs.cmd, aliases)
 	alias, aliases = fs.optionAliasSetup(aliases)
 	o = fs.flagSet.Float64(alias, defaultValue, description)
-	fs.Options[alias] = Option{Type: OptionFloat, value: o}
+	fs.optionAdd(alias, aliases, Option{Type: OptionFloat, checks: checks, value: o})
 	return o
 }
 
-func (fs *FlagSet) OptionInt(aliases []string, defaultValue int, description string) (o *int) {
+// optionGroupAdd registers an option group constraint
+func (fs *FlagSet) optionGroupAdd(kind optionGroupType, aliases []string) {
+	group := optionGroup{kind: kind}
+	for _, alias := range aliases {
+		name, ok := fs.optionName(alias)
+		if !ok {
+			panic(fmt.Sprintf("cannot group undefined option %q", alias))
+		}
+		group.names = append(group.names, name)
+	}
+	fs.optionGroups = append(fs.optionGroups, group)
+}
+
+// optionGroupUsage describes an option group constraint for help output
+func (fs *FlagSet) optionGroupUsage(group optionGroup) string {
+	names := make([]string, len(group.names))
+	for i, name := range group.names {
+		names[i] = fs.optionAliases["--"+name]
+	}
+
+	switch group.kind {
+	case groupMutuallyExclusive:
+		return "only one of " + strings.Join(names, ", ") + " may be used"
+	case groupOneRequired:
+		return "one of " + strings.Join(names, ", ") + " is required"
+	default:
+		return strings.Join(names, ", ") + " must be used together"
+	}
+}
+
+func (fs *FlagSet) OptionInt(aliases []string, defaultValue int, description string, checks ...OptionCheck) (o *int) {
 	var alias string
 
 	// log.Printf("krait.FlagSet.OptionInt() | %q | aliases: %q\n", fs.cmd, aliases)
@@ -394,21 +956,143 @@ func (fs *FlagSet) OptionInt(aliases []string, defaultValue int, description str
 	// log.Printf("krait.FlagSet.OptionInt() | %q | aliases: %q\n", fs.cmd, aliases)
 	// log.Printf("krait.FlagSet.OptionInt() | %q | defaultValue: %d | description: %q\n", fs.cmd, defaultValue, description)
 	o = fs.flagSet.Int(alias, defaultValue, description)
-	fs.Options[alias] = Option{Type: OptionInt, value: o}
+	fs.optionAdd(alias, aliases, Option{Type: OptionInt, checks: checks, value: o})
 	// log.Printf("krait.FlagSet.OptionInt() | %q | fs.Options[%q]: %v\n", fs.cmd, alias, fs.Options[alias])
 	return o
 }
 
-func (fs *FlagSet) OptionUint(aliases []string, defaultValue uint, description string) (o *uint) {
+// OptionMap defines a key=value map option. Pairs may be supplied by
+// repeating the option (--label env=prod --label team=core) or as a comma
+// separated list (--label env=prod,team=core).
+func (fs *FlagSet) OptionMap(aliases []string, description string, checks ...OptionCheck) (o map[string]string) {
+	var alias string
+
+	alias, aliases = fs.optionAliasSetup(aliases)
+	o = make(map[string]string)
+	fs.flagSet.Var(mapValue(o), alias, description)
+	fs.optionAdd(alias, aliases, Option{Type: OptionMap, checks: checks, value: o})
+	return o
+}
+
+// optionIsSet returns true if the option was explicitly set when parsing
+func (fs *FlagSet) optionIsSet(name string) (set bool) {
+	return fs.Options[name].IsSet()
+}
+
+// optionName returns the canonical name of an option given any of its
+// aliases with or without a prefix
+func (fs *FlagSet) optionName(alias string) (name string, ok bool) {
+	if !strings.HasPrefix(alias, "-") {
+		alias = "-" + alias
+		if len(alias) > 2 {
+			alias = "-" + alias
+		}
+	}
+
+	canonical, ok := fs.lookupOption(alias)
+	if ok {
+		name, _, _ = strings.Cut(strings.TrimLeft(canonical, "-"), "=")
+	}
+	return name, ok
+}
+
+// OptionString defines a string option
+func (fs *FlagSet) OptionString(aliases []string, defaultValue string, description string, checks ...OptionCheck) (o *string) {
+	var alias string
+
+	alias, aliases = fs.optionAliasSetup(aliases)
+	o = fs.flagSet.String(alias, defaultValue, description)
+	fs.optionAdd(alias, aliases, Option{Type: OptionString, checks: checks, value: o})
+	return o
+}
+
+// OptionStringOptional defines a string option whose option-argument is
+// optional. When the option is given without an option-argument attached via
+// an equal sign it is set to noValue, so --color could mean "auto" while
+// --color=never is explicit. A back-quoted name in the description is used as
+// the option-argument placeholder in help output, e.g. --color[=WHEN].
+func (fs *FlagSet) OptionStringOptional(aliases []string, defaultValue string, noValue string, description string, checks ...OptionCheck) (o *string) {
+	var alias string
+
+	alias, aliases = fs.optionAliasSetup(aliases)
+	o = fs.flagSet.String(alias, defaultValue, description)
+	fs.optionAdd(alias, aliases, Option{
+		Type:     OptionString,
+		checks:   checks,
+		noValue:  noValue,
+		optional: true,
+		value:    o,
+	})
+	return o
+}
+
+func (fs *FlagSet) OptionUint(aliases []string, defaultValue uint, description string, checks ...OptionCheck) (o *uint) {
 	var alias string
 
 	// log.Printf("krait.FlagSet.OptionInt() | %q | aliases: %q\n", fs.cmd, aliases)
 	alias, aliases = fs.optionAliasSetup(aliases)
 	o = fs.flagSet.Uint(alias, defaultValue, description)
-	fs.Options[alias] = Option{Type: OptionUint, value: o}
+	fs.optionAdd(alias, aliases, Option{Type: OptionUint, checks: checks, value: o})
 	return o
 }
 
+// OptionValue defines an option of any type implementing flag.Value. A value
+// that has an IsBoolFlag method returning true takes no option-argument. A
+// value that accumulates must implement ResettableValue to be reset by Reset
+// and repeated calls to Parse.
+func (fs *FlagSet) OptionValue(aliases []string, value flag.Value, description string, checks ...OptionCheck) {
+	var alias string
+
+	alias, aliases = fs.optionAliasSetup(aliases)
+	fs.flagSet.Var(value, alias, description)
+	fs.optionAdd(alias, aliases, Option{Type: OptionValue, checks: checks, value: value})
+}
+
+// optionSuggestions returns the prefixed option aliases similar to name
+func (fs *FlagSet) optionSuggestions(name string) (found []string) {
+	candidates := []string{}
+	for alias, canonical := range fs.optionAliases {
+		if fs.optionVisible(canonical) {
+			candidates = append(candidates, strings.TrimLeft(alias, "-"))
+		}
+	}
+
+	for _, suggestion := range suggestions(strings.TrimLeft(name, "-"), candidates) {
+		if len(suggestion) == 1 {
+			found = append(found, "-"+suggestion)
+		} else {
+			found = append(found, "--"+suggestion)
+		}
+	}
+	return found
+}
+
+// optionUsageName returns the option name as displayed in help output
+func (fs *FlagSet) optionUsageName(f *flag.Flag) (name string) {
+	o := fs.Options[f.Name]
+
+	switch {
+	case o.negatable:
+		name = "--[no-]" + f.Name
+	case o.optional:
+		placeholder := "VALUE"
+		if strings.Contains(f.Usage, "`") {
+			placeholder, _ = flag.UnquoteUsage(f)
+		}
+		name = fs.optionAliases["--"+f.Name] + "[=" + placeholder + "]"
+	default:
+		name = "-" + f.Name
+	}
+
+	return name
+}
+
+// OneRequired declares that at least one of the named options must be set.
+// Any alias may be used to name an option.
+func (fs *FlagSet) OneRequired(names ...string) {
+	fs.optionGroupAdd(groupOneRequired, names)
+}
+
 func (fs *FlagSet) ParentName() string {
 	if fs == nil || fs.parent == nil {
 		return "nil"
@@ -416,7 +1100,94 @@ func (fs *FlagSet) ParentName() string {
 	return fs.parent.cmd
 }
 
-func (fs *FlagSet) parseSubCMD(args []string, level int, subcommand string) (subcmd string, lvl int, err error) {
+// parseArguments checks the number of bare arguments and assigns them to any
+// named arguments
+func (fs *FlagSet) parseArguments(args []string) (err error) {
+	min, max := fs.argsRange()
+
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		var expected string
+		switch {
+		case max == 0:
+			expected = "no arguments"
+		case min == max:
+			expected = fmt.Sprintf("exactly %d", min)
+		case max < 0:
+			expected = fmt.Sprintf("at least %d", min)
+		default:
+			expected = fmt.Sprintf("between %d and %d", min, max)
+		}
+		return fs.parseError(ErrArgCount, "", -1, "%s: %q expects %s, got %d", ErrorArgumentCount, strings.Join(fs.getCommandList(), " "), expected, len(args))
+	}
+
+	for _, spec := range fs.argSpecs {
+		n := spec.max
+		if n < 0 || n > len(args) {
+			n = len(args)
+		}
+		if n == 0 && spec.min == 0 {
+			continue
+		}
+		if err = spec.set(args[:n]...); err != nil {
+			return fs.parseError(ErrInvalidValue, args[0], -1, "%s %s %q: %v", ErrorInvalidArgument, spec.name, strings.Join(args[:n], " "), err)
+		}
+		args = args[n:]
+	}
+
+	return err
+}
+
+// parseOptions sets the options found in args and collects the bare
+// arguments that follow them. The offset is the position of args[0] in the
+// command line given to Parse and is used for error reporting.
+func (fs *FlagSet) parseOptions(args []string, offset int) (err error) {
+	tokens := fs.tokenize(args)
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		position := offset + token.Position
+		raw := args[token.Position]
+
+		switch token.Type {
+		case ArgBareArgument:
+			fs.args = append(fs.args, token.Value)
+
+		case ArgOptionAlias:
+			name, _, _ := strings.Cut(token.Value, "=")
+			canonical, ok := fs.lookupOption(name)
+			bare := strings.TrimLeft(name, "-")
+
+			switch {
+			case !ok && (bare == "h" || bare == "help"):
+				return fs.parseError(ErrHelpRequested, raw, position, "help requested")
+			case !ok:
+				return fs.parseError(ErrUnknownOption, name, position, "%s %q for %q%s", ErrorUnknownOption, name, strings.Join(fs.getCommandList(), " "), didYouMean(fs.optionSuggestions(name)))
+			case strings.Contains(canonical, "="):
+				return fs.parseError(ErrInvalidValue, raw, position, "invalid value %q: %s takes no option-argument", raw, name)
+			}
+
+			optionName := strings.TrimLeft(canonical, "-")
+			if !fs.optionAvailable(optionName) {
+				return fs.parseError(ErrUnknownOption, name, position, "experimental option %s for %q is not enabled%s", name, strings.Join(fs.getCommandList(), " "), fs.experimentalHint())
+			}
+			value := "true"
+			if i+1 < len(tokens) && tokens[i+1].Type == ArgOptionArgument {
+				i++
+				value = tokens[i].Value
+			} else if fs.optionArity(canonical) != arityNone {
+				return fs.parseError(ErrMissingValue, raw, position, "missing value for option %s", fs.optionDisplayName(optionName))
+			}
+
+			if err = fs.optionSet(optionName, value, Source{Kind: SourceCommandLine, Position: position}); err != nil {
+				return fs.parseError(ErrInvalidValue, raw, position, "invalid value %q for option %s: %v", value, fs.optionDisplayName(optionName), err)
+			}
+		}
+	}
+
+	return err
+}
+
+func (fs *FlagSet) parseSubCMD(args []string, level int, subcommand string) (subFS *FlagSet, rest []string, err error) {
 	// log.Printf("krait.FlagSet.parseSubCMD() | %q | levels: %d:%d | subcommand: %q | args: %q (start)\n", fs.cmd, fs.level, level, subcommand, args)
 
 	if level == 0 {
@@ -426,10 +1197,25 @@ func (fs *FlagSet) parseSubCMD(args []string, level int, subcommand string) (sub
 				For now we don't care but would likely be useful feature in a later version. ~RuneImp
 			*/
 		}
-		subcmd, lvl, err = fs.parseSubCMD(args[1:], 1, args[0]) // NOTE: should probably use fs.cmd instead of args[0] but seems less flexible
+		subFS, rest, err = fs.parseSubCMD(args[1:], 1, args[0]) // NOTE: should probably use fs.cmd instead of args[0] but seems less flexible
 	} else {
+		if subcommand == "--" {
+			// Everything that follows -- is an argument even if it looks like a valid subcommand
+			return subFS, rest, err
+		}
+
+		// User aliases of the subcommands at this level expand in place
+		words, aliasErr := fs.expandAliases(append([]string{subcommand}, args...), level)
+		if aliasErr != nil {
+			return subFS, rest, aliasErr
+		}
+		subcommand, args = words[0], words[1:]
+
 		arg := subcommand
-		argCmd, isSubCMD := fs.argIsSubcommand(arg, level)
+		argCmd, isSubCMD := fs.argIsSubcommand(arg)
+		if isSubCMD && !fs.subcommands[argCmd].commandAvailable() {
+			return subFS, rest, fs.parseError(ErrUnknownCommand, arg, level, "experimental command %q for %q is not enabled%s", arg, strings.Join(fs.getCommandList(), " "), fs.experimentalHint())
+		}
 		// log.Printf("krait.FlagSet.parseSubCMD() | %q | levels: %d:%d | arg: %q | argCmd: %q | isSubCMD: %t\n", fs.cmd, fs.level, level, arg, argCmd, isSubCMD)
 
 		// NOTE: this logic probably needs lots more work within this method ~RuneImp
@@ -443,26 +1229,22 @@ func (fs *FlagSet) parseSubCMD(args []string, level int, subcommand string) (sub
 		// 	}
 		// }
 
+		if !isSubCMD && !strings.HasPrefix(arg, "-") && !fs.acceptsBareArgs() {
+			err = fs.parseError(ErrUnknownCommand, arg, level, "%s %q for %q%s", ErrorInvalidCommand, arg, strings.Join(fs.getCommandList(), " "), didYouMean(quoteAll(suggestions(arg, fs.visibleSubcommandNames()))))
+		}
+
 		if isSubCMD {
 			// The 1st argument was a subcommand so keep drilling down
-
-			subcmd = argCmd
-			lvl = level
-			// log.Printf("krait.FlagSet.parseSubCMD() | %q | levels: %d:%d | args: %q | subcmd: %q | len(args): %d\n", fs.cmd, fs.level, level, args, subcmd, len(args))
+			subFS = fs.subcommands[argCmd]
+			rest = args
+			// log.Printf("krait.FlagSet.parseSubCMD() | %q | levels: %d:%d | args: %q | subcmd: %q | len(args): %d\n", fs.cmd, fs.level, level, args, argCmd, len(args))
 
 			if len(args) > 0 {
 				// Recursive check for more subcommands if there are enough arguments to allow for more subcommands
-				var (
-					argTmp string
-					errTmp error
-					lvlTmp int
-				)
-				argTmp, lvlTmp, errTmp = fs.parseSubCMD(args[1:], level+1, args[0])
-				// log.Printf("krait.FlagSet.parseSubCMD() | %q | levels: %d:%d | args: %q | argTmp: %q | lvlTmp: %d | errTmp: %v\n", fs.cmd, fs.level, level, args, argTmp, lvlTmp, errTmp)
-
-				if argTmp != "" {
-					subcmd = argTmp
-					lvl = lvlTmp
+				deeper, deeperRest, errTmp := subFS.parseSubCMD(args[1:], level+1, args[0])
+				if deeper != nil {
+					subFS = deeper
+					rest = deeperRest
 				}
 				if errTmp != nil {
 					err = errTmp
@@ -471,15 +1253,19 @@ func (fs *FlagSet) parseSubCMD(args []string, level int, subcommand string) (sub
 		}
 	}
 
-	// log.Printf("krait.FlagSet.parseSubCMD() | %q | levels: %d:%d | subcmd: %q | err: %v (end)\n", fs.cmd, fs.level, level, subcmd, err)
-	return subcmd, lvl, err
+	// log.Printf("krait.FlagSet.parseSubCMD() | %q | levels: %d:%d | subFS: %v | err: %v (end)\n", fs.cmd, fs.level, level, subFS, err)
+	return subFS, rest, err
 }
 
+// Parse parses the command line, os.Args by default, for the root FlagSet
+// and its subcommands. The input is never modified and a FlagSet that was
+// already parsed is Reset first so Parse may be called repeatedly.
 func (fs *FlagSet) Parse(input ...[]string) (subcmd string, err error) {
 	args := os.Args
 	if len(input) > 0 {
 		args = input[0]
 	}
+	args = append([]string(nil), args...)
 	// log.Printf("krait.FlagSet.Parse() | %q | args: %q\n", fs.cmd, args)
 	// log.Printf("krait.FlagSet.Parse() | %q | fs.flagSet.Name(): %q\n", fs.cmd, fs.flagSet.Name())
 	// log.Printf("krait.FlagSet.Parse() | %q | fs.ParentName(): %s\n", fs.cmd, quoteNotNil(fs.ParentName()))
@@ -500,52 +1286,103 @@ func (fs *FlagSet) Parse(input ...[]string) (subcmd string, err error) {
 		panic("the Parse method should only be called on the root FlagSet")
 	}
 
+	if fs.isParsed {
+		fs.Reset()
+	}
+	fs.isParsed = true
+
 	// Sanity Check: the args slice should always have the command name for the base FlagSet at the very least
 	if len(args) == 0 {
-		err = fmt.Errorf(ErrorInvalidCommand)
-		return subcmd, err
+		err = fs.parseError(ErrUnknownCommand, "", -1, ErrorInvalidCommand)
+		return subcmd, fs.handleError(err)
+	}
+
+	if args, err = fs.expandResponseFiles(args); err != nil {
+		return subcmd, fs.handleError(err)
 	}
+	args = fs.parseExperimentalOption(args)
 
 	// Check if there is a default subcommand to implement
 	if len(args) == 1 {
-		err = fmt.Errorf(ErrorNoArguments)
-		if fs.DefaultSubCommand != "" {
-			args = append(args, fs.DefaultSubCommand)
+		if fs.DefaultSubCommand == "" {
+			err = fs.parseError(ErrNoArguments, "", -1, ErrorNoArguments)
+			return subcmd, fs.handleError(err)
 		}
+		args = append(args, fs.DefaultSubCommand)
 	}
 
-	level := 0
+	// Root user aliases expand before plugins are looked up, deeper ones in parseSubCMD
+	expanded, err := fs.expandAliases(args[1:], 1)
+	if err != nil {
+		return subcmd, fs.handleError(err)
+	}
+	args = append(args[:1], expanded...)
+
+	if path, ok := fs.lookPlugin(args[1]); ok {
+		fs.subcmd = args[1]
+		return args[1], fs.runPlugin(args[1], path, args[2:])
+	}
 
-	subcmd, level, err = fs.parseSubCMD(args[1:], 0, args[0])
+	subFS, rest, err := fs.parseSubCMD(args[1:], 0, args[0])
+	if subFS != nil {
+		subcmd = subFS.cmd
+	}
+	if err != nil {
+		fs.subcmd = subcmd
+		if subFS != nil {
+			return subcmd, subFS.handleError(err)
+		}
+		return subcmd, fs.handleError(err)
+	}
 
 	// log.Printf("krait.FlagSet.Parse() | %q | level: %d | subcmd: %q\n", fs.cmd, level, subcmd)
 
-	if subFS, ok := fs.subcommands[level][subcmd]; ok {
-		args = args[level+1:]
+	if subFS != nil {
+		level := subFS.level
+		args = rest
 		// log.Printf("krait.FlagSet.Parse() | %q | level: %d | subcmd: %q | valid: %t\n", fs.cmd, level, subcmd, ok)
 		// log.Printf("krait.FlagSet.Parse() | %q | args: %q\n", fs.cmd, args)
 
+		for _, f := range subFS.getCommandChain() {
+			f.isParsed = true
+		}
+		subFS.args = []string{}
 		if len(args) > 0 {
-			// Manage option aliases in args before parsing
-			for i, a := range args {
-				// log.Printf("krait.FlagSet.Parse() | %q | alias fix | a: %q | subFS.optionAliases: %q\n", subFS.cmd, a, subFS.optionAliases)
-				for k, v := range subFS.optionAliases {
-					// log.Printf("krait.FlagSet.Parse() | %q | alias fix | k: %q\n", subFS.cmd, k)
-					if a == k {
-						// log.Printf("krait.FlagSet.Parse() | %q | alias fix | v: %q\n", subFS.cmd, v)
-						args[i] = v
-					}
+			err = subFS.parseOptions(args, level+1)
+		}
+		fs.args = subFS.args
+
+		if err == nil {
+			err = subFS.applyEnv()
+		}
+		if err == nil {
+			err = subFS.applyConfig()
+		}
+		if err == nil {
+			err = subFS.applyChecks()
+		}
+		if err == nil {
+			err = subFS.checkRequired()
+		}
+		if err == nil {
+			err = subFS.checkGroups()
+		}
+		if err == nil {
+			err = subFS.parseArguments(subFS.args)
+		}
+		if err == nil {
+			subFS.warnDeprecated()
+			for _, cfs := range subFS.getCommandChain() {
+				for _, bind := range cfs.bindings {
+					bind()
 				}
 			}
-			// log.Printf("krait.FlagSet.Parse() | %q | level: %d | subFS.flagSet.Parse(args) | args: %q\n", fs.cmd, level, args)
-
-			// subcmd, err = subFS.Parse(args)
-			// _, err = subFS.Parse(args)
-			err = subFS.flagSet.Parse(args)
-			fs.getRoot().args = subFS.flagSet.Args()
+		}
+		if err != nil {
+			err = subFS.handleError(err)
 		}
 
-		if subFS.CmdFunc != nil {
+		if subFS.CmdFunc != nil && err == nil {
 			if len(args) > 0 {
 				// log.Printf("krait.FlagSet.Parse() | %q | level: %d | subFS.CmdFunc(subFS, args...) | args: %q\n", fs.cmd, level, args)
 				subFS.CmdFunc(subFS, args...)
@@ -553,6 +1390,9 @@ func (fs *FlagSet) Parse(input ...[]string) (subcmd string, err error) {
 				// log.Printf("krait.FlagSet.Parse() | %q | level: %d | subFS.CmdFunc(subFS)\n", fs.cmd, level)
 				subFS.CmdFunc(subFS)
 			}
+			// Built-in commands such as help report their outcome here under ContinueOnError
+			err = fs.exitErr
+			fs.exitErr = nil
 		}
 	} else {
 		// log.Printf("krait.FlagSet.Parse() | %q | subcmd: %q | valid: false\n", fs.cmd, subcmd)
@@ -560,7 +1400,7 @@ func (fs *FlagSet) Parse(input ...[]string) (subcmd string, err error) {
 
 	// log.Printf("krait.FlagSet.Parse() | %q | subcmd: %q | err: %v\n", fs.cmd, subcmd, err)
 
-	fs.getRoot().subcmd = subcmd
+	fs.subcmd = subcmd
 
 	return subcmd, err
 }
@@ -570,6 +1410,67 @@ func (fs *FlagSet) Parsed() bool {
 	return fs.isParsed
 }
 
+// Reset returns every FlagSet of the tree to its state before Parse. Option
+// values go back to their defaults and stop counting as set, named
+// arguments are zeroed and the active subcommand and arguments are cleared.
+func (fs *FlagSet) Reset() {
+	rfs := fs.getRoot()
+	rfs.exitErr = nil
+	rfs.experimentalOn = false
+
+	for _, f := range rfs.getCommandTree() {
+		f.args = nil
+		f.isParsed = false
+		for _, o := range f.Options {
+			if o.state != nil {
+				*o.state = optionState{}
+			}
+		}
+		f.subcmd = ""
+		f.resetOptions()
+
+		for _, spec := range f.argSpecs {
+			switch a := spec.value.(type) {
+			case *float64:
+				*a = 0
+			case *int:
+				*a = 0
+			case *string:
+				*a = ""
+			case *[]string:
+				*a = nil
+			case *uint:
+				*a = 0
+			}
+		}
+	}
+}
+
+// resetOptions sets every option back to its default value. The
+// flag.FlagSet is replaced as it has no other way to forget which options
+// were set.
+func (fs *FlagSet) resetOptions() {
+	flagSet := flag.NewFlagSet(fs.flagSet.Name(), flag.ContinueOnError)
+	flagSet.SetOutput(fs.flagSet.Output())
+	flagSet.Usage = fs.flagSet.Usage
+
+	fs.flagSet.VisitAll(func(f *flag.Flag) {
+		if rv, ok := f.Value.(ResettableValue); ok {
+			rv.Reset()
+		}
+		if f.DefValue != "" || f.Value.String() != "" {
+			f.Value.Set(f.DefValue)
+		}
+		flagSet.Var(f.Value, f.Name, f.Usage)
+		if o, ok := fs.Options[f.Name]; ok {
+			o.flag = flagSet.Lookup(f.Name)
+			fs.Options[f.Name] = o
+		}
+	})
+
+	fs.flagSet = flagSet
+}
+
 // func (k FlagSet) String(name string, defaultValue string, description string) *string {
 // 	p := fs.flagSet.String(name, defaultValue, description)
 // 	return p
@@ -579,22 +1480,64 @@ func (fs *FlagSet) Parsed() bool {
 // 	fs.flagSet.StringVar(p, name, defaultValue, description)
 // }
 
+// Require marks the named options as required. Any alias may be used to name
+// an option. Parse returns an error listing every required option of the
+// active command chain that was not set. Options of the root FlagSet are
+// never parsed from the command line so they cannot be required.
+func (fs *FlagSet) Require(names ...string) {
+	if fs.parent == nil {
+		panic(fmt.Sprintf("cannot require options %q of the root %q: root options are never parsed", names, fs.cmd))
+	}
+	for _, alias := range names {
+		name, ok := fs.optionName(alias)
+		if !ok {
+			panic(fmt.Sprintf("cannot require undefined option %q", alias))
+		}
+		o := fs.Options[name]
+		o.required = true
+		fs.Options[name] = o
+	}
+}
+
+// RequiredTogether declares that either all or none of the named options
+// must be set. Any alias may be used to name an option.
+func (fs *FlagSet) RequiredTogether(names ...string) {
+	fs.optionGroupAdd(groupRequiredTogether, names)
+}
+
+// subcommandNames returns the sorted names and aliases of the subcommands of
+// this FlagSet
+func (fs *FlagSet) subcommandNames() (names []string) {
+	for name := range fs.subcommands {
+		names = append(names, name)
+	}
+	for alias := range fs.subcmdAliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 // SubCommand returns the name of the active subcommand
 func (fs *FlagSet) SubCommand() string {
 	return fs.getRoot().subcmd
 }
 
-// SubcommandAlias links the supplied alias to the specified subcommand
+// SubcommandAlias links the supplied alias to the specified subcommand. It
+// panics if an alias is already used by another subcommand of the same
+// parent, such as the built-in help, hlp, version and ver of the root.
 func (fs *FlagSet) SubcommandAlias(aliases ...string) {
 	// log.Printf("krait.FlagSet.SubcommandAlias() | fs.level: %d | fs.cmd: %q | alias: %q\n", fs.level, fs.cmd, aliases)
 
-	rfs := fs.getRoot()
-
-	if rfs.subcmdAliases[fs.level] == nil {
-		rfs.subcmdAliases[fs.level] = make(map[string]string)
+	if fs.parent == nil {
+		panic(fmt.Sprintf("cannot define aliases %q for the root %q", aliases, fs.cmd))
 	}
 	for _, alias := range aliases {
-		rfs.subcmdAliases[fs.level][alias] = fs.cmd
+		if owner, ok := fs.parent.commandOwner(alias); ok && owner != fs {
+			panic(fmt.Sprintf("cannot define alias %q for %q: already used by %q", alias, strings.Join(fs.getCommandList(), " "), strings.Join(owner.getCommandList(), " ")))
+		}
+		fs.parent.subcmdAliases[alias] = fs.cmd
 	}
 
 	// for lvl := range rfs.subcmdAliases {
@@ -604,20 +1547,107 @@ func (fs *FlagSet) SubcommandAlias(aliases ...string) {
 	// }
 }
 
-func NewFlagSet(name string) (fs *FlagSet) {
+// terminate ends command line processing with err according to the
+// ErrorHandling of the FlagSet. Under ContinueOnError err is returned.
+func (fs *FlagSet) terminate(err error) error {
+	switch fs.ErrorHandling() {
+	case ExitOnError:
+		fs.getRoot().Exit(fs.ExitCode(err))
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// tokenize classifies the arguments following a subcommand as option aliases,
+// option-arguments and bare arguments following the rules of doc/logic.md.
+// Option aliases are resolved to their canonical prefixed names and
+// option-arguments attached with = are split from their options. Option
+// parsing ends at the first bare argument or at --, just as it does for
+// flag.FlagSet. Unknown options are passed through untouched as option alias
+// tokens for parseOptions to report with suggestions.
+func (fs *FlagSet) tokenize(args []string) (tokens []Argument) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			tokens = append(tokens, Argument{Type: ArgEndOfOptions, Value: arg, Position: i})
+			for j, a := range args[i+1:] {
+				tokens = append(tokens, Argument{Type: ArgBareArgument, Value: a, Position: i + 1 + j})
+			}
+			break
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			for j, a := range args[i:] {
+				tokens = append(tokens, Argument{Type: ArgBareArgument, Value: a, Position: i + j})
+			}
+			break
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		canonical, ok := fs.lookupOption(name)
+		if !ok && !hasValue && len(name) > 2 && name[1] != '-' {
+			// POSIX option with its option-argument as a suffix: -c3
+			if short, found := fs.optionAliases[name[:2]]; found && fs.optionArity(short) != arityNone {
+				canonical, ok = short, true
+				value, hasValue = name[2:], true
+			}
+		}
+		if !ok {
+			tokens = append(tokens, Argument{Type: ArgOptionAlias, Value: arg, Position: i})
+			continue
+		}
+
+		if negated, v, isNegation := strings.Cut(canonical, "="); isNegation {
+			// --no-<name> resolves to --<name>=false and accepts no option-argument
+			if hasValue {
+				tokens = append(tokens, Argument{Type: ArgOptionAlias, Value: arg, Position: i})
+				continue
+			}
+			canonical, value, hasValue = negated, v, true
+		}
+
+		tokens = append(tokens, Argument{Type: ArgOptionAlias, Value: canonical, Position: i})
+
+		switch {
+		case hasValue:
+			tokens = append(tokens, Argument{Type: ArgOptionArgument, Value: value, Position: i})
+		case fs.optionArity(canonical) == arityRequired && i+1 < len(args):
+			i++
+			tokens = append(tokens, Argument{Type: ArgOptionArgument, Value: args[i], Position: i})
+		case fs.optionArity(canonical) == arityOptional:
+			tokens = append(tokens, Argument{Type: ArgOptionArgument, Value: fs.Options[strings.TrimLeft(canonical, "-")].noValue, Position: i})
+		}
+	}
+
+	return tokens
+}
+
+// NewFlagSet returns a new root FlagSet. The ErrorHandling defaults to
+// ExitOnError and is inherited by every subcommand that does not set its own.
+func NewFlagSet(name string, errHandler ...flag.ErrorHandling) (fs *FlagSet) {
 	// log.Printf("krait.NewFlagSet() | name: %q\n", name)
+	errorHandling := ExitOnError
+	if len(errHandler) > 0 {
+		errorHandling = errHandler[0]
+	}
 
 	// Root FlagSet
 	fs = &FlagSet{
 		cmd:               name,
 		DefaultSubCommand: "help", // DefaultSubCommand defines a subcommand to use when non is specified on the command line which is "help" default
-		flagSet:           flag.NewFlagSet(name, flag.ExitOnError),
+		errorHandling:     &errorHandling,
+		Exit:              os.Exit,
+		ExitCodes:         make(map[error]int),
+		flagSet:           flag.NewFlagSet(name, flag.ContinueOnError), // Errors are handled by Krait
 		HelpOutput:        helpOutput,
 		level:             0,
+		NArgs:             -1,
 		optionAliases:     make(map[string]string),
 		Options:           make(map[string]Option),
-		subcmdAliases:     make([]map[string]string, 2),
-		subcommands:       make([]map[string]*FlagSet, 2),
+		subcmdAliases:     make(map[string]string),
+		subcommands:       make(map[string]*FlagSet),
 		// Epilogue:      "",
 		// Options:       make(map[string]any),
 		// Options:       make(map[string]Optional),
@@ -652,13 +1682,17 @@ func NewFlagSet(name string) (fs *FlagSet) {
 		}
 	*/
 
-	verFS := fs.NewFlagSet("version", flag.ExitOnError)
+	for sentinel, code := range DefaultExitCodes {
+		fs.ExitCodes[sentinel] = code
+	}
+
+	verFS := fs.NewFlagSet("version")
 	verFS.CmdFunc = cmdVersion
 	verFS.Summery = "Displays the app name and version"
 	// verFS.SubcommandAlias("version", "ver")
 	verFS.SubcommandAlias("ver")
 
-	helpFS := fs.NewFlagSet("help", flag.ExitOnError)
+	helpFS := fs.NewFlagSet("help")
 	helpFS.CmdFunc = cmdHelp
 	helpFS.HelpOutput = helpOutput
 	helpFS.Summery = "Displays this help information"
@@ -669,8 +1703,50 @@ func NewFlagSet(name string) (fs *FlagSet) {
 }
 
 type Option struct {
-	Type  string
-	value any
+	Type         string
+	aliases      []string      // Canonical name followed by the other aliases, without prefixes
+	checks       []OptionCheck // Validators and transformers run after parsing
+	deprecated   string        // Warning reported when the option is set, empty if not deprecated
+	env          string        // Environment variable used when the option is not set on the command line
+	experimental bool          // The option is unknown unless experimental features are enabled
+	flag         *flag.Flag    // Holds the usage and default value
+	hidden       bool          // The option is omitted from help and completion
+	negatable    bool          // Boolean option that also accepts --no-<name>
+	noValue      string        // Value used when an optional option-argument is omitted
+	optional     bool          // The option-argument is optional and must be attached with =
+	required     bool          // The option must be set
+	state        *optionState  // If and how the option was set, shared by every copy of the Option
+	value        any
+}
+
+// Aliases returns the canonical name of the option followed by its other
+// aliases in the order they were defined, without dash prefixes
+func (o Option) Aliases() []string {
+	return append([]string(nil), o.aliases...)
+}
+
+// Default returns the default value of the option as shown in help output
+func (o Option) Default() string {
+	if o.flag == nil {
+		return ""
+	}
+	return o.flag.DefValue
+}
+
+// Name returns the canonical name of the option, its longest alias
+func (o Option) Name() string {
+	if len(o.aliases) == 0 {
+		return ""
+	}
+	return o.aliases[0]
+}
+
+// Usage returns the description of the option
+func (o Option) Usage() string {
+	if o.flag == nil {
+		return ""
+	}
+	return o.flag.Usage
 }
 
 // GetBool returns boolean true or false for a given value. If the value is
@@ -693,7 +1769,7 @@ func (o Option) GetBool() (result bool, err error) {
 		}
 	case OptionString:
 		// result = false // result is false by default
-		if o.value.(string) != "" {
+		if *o.value.(*string) != "" {
 			result = true
 		}
 	default:
@@ -716,7 +1792,7 @@ func (o Option) GetFloat() (result float64, err error) {
 	case OptionInt:
 		result = float64(o.value.(int))
 	case OptionString:
-		result, err = strconv.ParseFloat(o.value.(string), 64)
+		result, err = strconv.ParseFloat(*o.value.(*string), 64)
 	case OptionUint:
 		result = float64(o.value.(uint))
 	default:
@@ -740,7 +1816,7 @@ func (o Option) GetInt() (result int, err error) {
 	case OptionInt:
 		result = *o.value.(*int)
 	case OptionString:
-		result, err = strconv.Atoi(o.value.(string))
+		result, err = strconv.Atoi(*o.value.(*string))
 	case OptionUint:
 		result = int(o.value.(uint))
 	default:
@@ -749,6 +1825,17 @@ func (o Option) GetInt() (result int, err error) {
 	return result, err
 }
 
+// GetMap returns the key=value pairs of a map option
+func (o Option) GetMap() (result map[string]string, err error) {
+	switch o.Type {
+	case OptionMap:
+		result = o.value.(map[string]string)
+	default:
+		err = fmt.Errorf("unhandled option type: %q", o.Type)
+	}
+	return result, err
+}
+
 func (o Option) GetString() (result string, err error) {
 	switch o.Type {
 	case OptionBool:
@@ -761,11 +1848,15 @@ func (o Option) GetString() (result string, err error) {
 		result = strconv.FormatFloat(o.value.(float64), 'f', -1, 64)
 	case OptionInt:
 		result = strconv.Itoa(o.value.(int))
+	case OptionMap:
+		result = mapValue(o.value.(map[string]string)).String()
 	case OptionString:
-		result = o.value.(string)
+		result = *o.value.(*string)
 	case OptionUint:
 		result = fmt.Sprintf("%d", o.value.(uint))
 		// result = strconv.FormatUint(uint64(o.value.(uint)), 10) // Possibly faster but seriously?
+	case OptionValue:
+		result = o.value.(flag.Value).String()
 	default:
 		err = fmt.Errorf("unhandled option type: %T", o.Type)
 	}
@@ -793,7 +1884,7 @@ func (o Option) GetUint() (result uint, err error) {
 	case OptionInt:
 		result = uint(*o.value.(*int))
 	case OptionString:
-		tmp, err = strconv.ParseUint(o.value.(string), 10, 64)
+		tmp, err = strconv.ParseUint(*o.value.(*string), 10, 64)
 		result = uint(tmp)
 	case OptionUint:
 		tmp := o.value.(*uint)
@@ -804,6 +1895,52 @@ func (o Option) GetUint() (result uint, err error) {
 	return result, err
 }
 
+// ResettableValue is a flag.Value that accumulates values, such as a list
+// each Set appends to. Reset empties it before its default is set again. Any
+// other value is only Set to its default, which adds to an accumulating
+// value rather than replacing it.
+type ResettableValue interface {
+	flag.Value
+	Reset()
+}
+
+// mapValue is the flag.Value used by FlagSet.OptionMap
+type mapValue map[string]string
+
+// Reset removes every key from the map
+func (m mapValue) Reset() {
+	for key := range m {
+		delete(m, key)
+	}
+}
+
+// Set adds one or more comma separated key=value pairs to the map
+func (m mapValue) Set(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid key=value pair %q: expected key=value", pair)
+		}
+		m[key] = value
+	}
+	return nil
+}
+
+func (m mapValue) String() string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + m[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
 func aliasSort(s []string) {
 	// log.Printf("krait.aliasSort() | s: %q\n", s)
 	posix := []string{}
@@ -874,7 +2011,7 @@ func cmdHelp(fs *FlagSet, args ...string) {
 			fs.HelpOutput(fs)
 		}
 	}
-	os.Exit(0)
+	fs.getRoot().exitErr = fs.terminate(ErrHelpRequested)
 }
 
 func cmdVersion(fs *FlagSet, args ...string) {
@@ -882,7 +2019,7 @@ func cmdVersion(fs *FlagSet, args ...string) {
 
 	fmt.Fprintln(flag.CommandLine.Output(), fs.getRoot().AppLabel)
 
-	os.Exit(0)
+	fs.getRoot().exitErr = fs.terminate(ErrVersionRequested)
 }
 
 func helpOutput(fs *FlagSet, args ...string) {
@@ -910,49 +2047,54 @@ func helpOutput(fs *FlagSet, args ...string) {
 		fmt.Println()
 		fmt.Fprintln(flag.CommandLine.Output(), rfs.AppLabel) // Initial help output is the Application Name and Version AKA Label
 
-		// Output for all commands
+		// Output for all commands, nested subcommands by their path below the root
 		var (
-			aliases       = make(map[string][]string)
+			aliases       = make(map[*FlagSet][]string)
+			cmdList       [][]*FlagSet
+			names         = make(map[*FlagSet]string)
 			widestCommand int
 		)
 
-		cmdList := make([][]string, 1)
-		for i := range cmdList {
-			// log.Printf("krait.helpOutput() | len(cmdList): %d\n", len(cmdList))
-			cmdList[i] = make([]string, 1)
-		}
-		// log.Printf("krait.helpOutput() | cmdList: %#v\n", cmdList)
-
-		for lvl := range rfs.subcommands {
-			// log.Printf("krait.helpOutput() | lvl: %d\n", lvl)
-			for subCmdName := range fs.parent.subcommands[lvl] {
-				// log.Printf("krait.helpOutput() | %d | subCmdName: %q | subCmdFS.flagSet.Name(): %q, (%d)\n", lvl, subCmdName, subCmdFS.flagSet.Name(), len(subCmdFS.flagSet.Name()))
-				// log.Printf("krait.helpOutput() | %d | subCmdName: %q, (%d)\n", lvl, subCmdName, len(subCmdName))
-				aliases[subCmdName] = fs.getSubCommandAliases(subCmdName)
-				// log.Printf("krait.helpOutput() | %d | aliases[%q]: %q\n", lvl, subCmdName, aliases[subCmdName])
-				if len(subCmdName) > widestCommand {
-					widestCommand = len(subCmdName)
-
-					// Check if there is a single alias and if there is it will
-					// be added to the subcommand width + 2 for the comma space
-					if len(aliases[subCmdName]) == 1 {
-						widestCommand += 2 + len(aliases[subCmdName])
-					}
-				}
-				for lvl >= len(cmdList) {
-					cmdList = append(cmdList, []string{})
-				}
-				cmdList[lvl] = append(cmdList[lvl], subCmdName)
-				// log.Printf("krait.helpOutput() | lvl: %d | len(cmdList): %d\n", lvl, len(cmdList))
+		for _, subCmdFS := range rfs.getCommandTree()[1:] {
+			if !subCmdFS.commandVisible() {
+				continue
+			}
+			names[subCmdFS] = strings.Join(subCmdFS.getCommandList()[1:], " ")
+			aliases[subCmdFS] = subCmdFS.getSubCommandAliases()
+			// A single alias is listed after the subcommand name and a comma space
+			width := len(names[subCmdFS])
+			if len(aliases[subCmdFS]) == 1 {
+				width += 2 + len(aliases[subCmdFS][0])
+			}
+			if width > widestCommand {
+				widestCommand = width
+			}
+			for subCmdFS.level >= len(cmdList) {
+				cmdList = append(cmdList, nil)
+			}
+			cmdList[subCmdFS.level] = append(cmdList[subCmdFS.level], subCmdFS)
+		}
+
+		// External plugins are listed after the registered subcommands
+		plugins := rfs.plugins()
+		pluginNames := make([]string, 0, len(plugins))
+		for name := range plugins {
+			pluginNames = append(pluginNames, name)
+			if len(name) > widestCommand {
+				widestCommand = len(name)
 			}
-			sort.Strings(cmdList[lvl])
-			// for i, subCmdName := range cmdList[lvl] {
-			// 	log.Printf("krait.helpOutput() | %d:%d | subCmdName: %q\n", lvl, i, subCmdName)
-			// }
-			// for subcmd, aliases := range aliases {
-			// 	log.Printf("krait.helpOutput() | %d | subcmd: %q | aliases: %q\n", lvl, subcmd, aliases)
-			// }
 		}
+		sort.Strings(pluginNames)
+
+		expansions := rfs.allUserAliases()
+		userAliases := make([]string, 0, len(expansions))
+		for name := range expansions {
+			userAliases = append(userAliases, name)
+			if len(name) > widestCommand {
+				widestCommand = len(name)
+			}
+		}
+		sort.Strings(userAliases)
 
 		format := fmt.Sprintf("  %%-%ds  %%s\n", widestCommand+3)
 		// format := fmt.Sprintf("  %%-%ds  %%s", widestCommand)
@@ -965,34 +2107,29 @@ func helpOutput(fs *FlagSet, args ...string) {
 		fmt.Fprintln(flag.CommandLine.Output())
 
 		fmt.Fprintln(flag.CommandLine.Output(), summeryTitle)
-		for lvl := range cmdList {
-			for _, cmdName := range cmdList[lvl] {
-				if len(cmdName) > 0 {
-					// log.Printf("krait.helpOutput() | cmdName: %q\n", cmdName)
-					subCmdFS := rfs.subcommands[lvl][cmdName]
-					// helpLine := fmt.Sprintf(format, subCmdFS.flagSet.Name(), subCmdFS.Summery)
-
-					if len(aliases[cmdName]) == 1 {
-						cmdName += ", " + aliases[cmdName][0]
-					}
-					fmt.Fprintf(flag.CommandLine.Output(), format, cmdName, subCmdFS.Summery)
-
-					// subCmdFS.flagSet.Usage()
-					// aliases := rfs.subcmdAliases[lvl][cmdName]
-					// aliases := fs.getSubCommandAliases(cmdName)
-					// log.Printf("krait.helpOutput() | rfs.subcmdAliases[%d][%q] = aliases: %q\n", lvl, cmdName, aliases)
-					if len(aliases[cmdName]) > 1 {
-						aliasList := ""
-						for _, alias := range aliases[cmdName] {
-							aliasList += ", " + alias
-						}
-						aliasList = aliasList[2:]
-						fmt.Fprintf(flag.CommandLine.Output(), "      aliases: %s\n", aliasList)
-					}
-					// fmt.Fprintf(flag.CommandLine.Output(), helpLine)
+		for _, list := range cmdList {
+			for _, subCmdFS := range list {
+				cmdName := names[subCmdFS]
+				if len(aliases[subCmdFS]) == 1 {
+					cmdName += ", " + aliases[subCmdFS][0]
+				}
+				fmt.Fprintf(flag.CommandLine.Output(), format, cmdName, subCmdFS.Summery)
+
+				if len(aliases[subCmdFS]) > 1 {
+					fmt.Fprintf(flag.CommandLine.Output(), "      aliases: %s\n", strings.Join(aliases[subCmdFS], ", "))
 				}
 			}
 		}
+		for _, name := range pluginNames {
+			fmt.Fprintf(flag.CommandLine.Output(), format, name, pluginMarker+" "+filepath.Base(plugins[name]))
+		}
+
+		if len(userAliases) > 0 {
+			fmt.Fprintln(flag.CommandLine.Output(), aliasTitle)
+			for _, name := range userAliases {
+				fmt.Fprintf(flag.CommandLine.Output(), format, name, strconv.Quote(expansions[name]))
+			}
+		}
 
 		/*
 
@@ -1034,25 +2171,41 @@ func helpOutput(fs *FlagSet, args ...string) {
 			}
 		*/
 	} else {
-		for i := range fs.parent.subcommands {
-			for _, subcmd := range fs.parent.subcommands[i] {
-				// log.Printf("krait.helpOutput() | subcmd.flagSet.Name(): %q\n", subcmd.flagSet.Name())
-				if subcmd.flagSet.Name() == cmdName {
-					// log.Printf("krait.helpOutput() | cmdName match: %q\n", cmdName)
-					subcmd.flagSet.Usage()
-					// flag.PrintDefaults()
-					// subcmd.flagSet.PrintDefaults()
-
-					// fmt.Fprintf(flag.CommandLine.Output(), subcmd.Summery)
+		// Follow the command path, help remote add, falling back to the first
+		// subcommand with the name anywhere in the tree
+		rfs := fs.getRoot()
+		subFS := rfs
+		for _, name := range append([]string{cmdName}, args...) {
+			subcmd, ok := subFS.argIsSubcommand(name)
+			if !ok {
+				break
+			}
+			subFS = subFS.subcommands[subcmd]
+		}
+		if subFS == rfs {
+			for _, f := range rfs.getCommandTree()[1:] {
+				if f.cmd == cmdName {
+					subFS = f
 					break
 				}
 			}
 		}
+		if subFS != rfs {
+			subFS.flagSet.Usage()
+		}
 	}
 
 	fmt.Fprintln(flag.CommandLine.Output())
 }
 
+// quoteAll returns a copy of list with every string quoted
+func quoteAll(list []string) (quoted []string) {
+	for _, s := range list {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return quoted
+}
+
 func quoteNotNil(s string) string {
 	if s == "nil" {
 		return s
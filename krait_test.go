@@ -1,7 +1,11 @@
 package krait
 
 import (
+	"bytes"
 	"flag"
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -212,3 +216,503 @@ func TestOptionAliases2(t *testing.T) {
 		t.Fatalf("got: %d | want: %d", got, want)
 	}
 }
+
+// TestOptionMap ensure map option parsing works with repeated and comma separated pairs
+func TestOptionMap(t *testing.T) {
+	want := map[string]string{"env": "prod", "team": "core", "tier": "web"}
+	args := []string{"krait", "test", "--label", "env=prod", "-l", "team=core,tier=web", "two"}
+	optionAliases := []string{"l", "label"}
+
+	root := NewFlagSet("root")
+	testFS := root.NewFlagSet("test")
+	testFS.CmdFunc = kraitTestFunction
+	labels := testFS.OptionMap(optionAliases, "Labels to apply")
+	root.Parse(args)
+
+	got, err := testFS.Options["label"].GetMap()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(got) != len(want) || len(labels) != len(want) {
+		t.Fatalf("got: %q | want: %q", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("got: %q | want: %q", got, want)
+		}
+	}
+}
+
+// TestOptionMapMalformed ensure malformed map pairs are reported
+func TestOptionMapMalformed(t *testing.T) {
+	args := []string{"krait", "test", "--label", "env"}
+	optionAliases := []string{"l", "label"}
+
+	root := NewFlagSet("root")
+	testFS := root.NewFlagSet("test", ContinueOnError)
+	testFS.OptionMap(optionAliases, "Labels to apply")
+	_, err := root.Parse(args)
+
+	if err == nil || !strings.Contains(err.Error(), `invalid key=value pair "env"`) {
+		t.Fatalf("got: %v | want: invalid key=value pair error", err)
+	}
+}
+
+// TestOptionBoolNegated ensure --no-<name> sets a boolean option to false
+func TestOptionBoolNegated(t *testing.T) {
+	want := false
+	args := []string{"krait", "test", "--no-color", "one"}
+	optionAliases := []string{"c", "color"}
+
+	root := NewFlagSet("root")
+	testFS := root.NewFlagSet("test")
+	testFS.CmdFunc = kraitTestFunction
+	color := testFS.OptionBool(optionAliases, true, "Colorize the output")
+	root.Parse(args)
+
+	got := *color
+
+	if got != want {
+		t.Fatalf("got: %t | want: %t", got, want)
+	}
+}
+
+// TestOptionBoolNegatedUsage ensure negatable options are shown as --[no-]name
+func TestOptionBoolNegatedUsage(t *testing.T) {
+	want := "--[no-]color    Colorize the output (default: true)"
+	var buf bytes.Buffer
+
+	root := NewFlagSet("root")
+	testFS := root.NewFlagSet("test")
+	testFS.OptionBool([]string{"c", "color"}, true, "Colorize the output")
+
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+	testFS.flagSet.Usage()
+
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("got: %q | want: %q", got, want)
+	}
+}
+
+// TestOptionAttachedValues ensure option-arguments attached with = or as a
+// POSIX suffix work for every alias form
+func TestOptionAttachedValues(t *testing.T) {
+	want := 3
+	optionAliases := []string{"c", "cnt", "count"}
+	forms := [][]string{
+		{"--count=3"},
+		{"-count=3"},
+		{"--cnt=3"},
+		{"-cnt=3"},
+		{"-c=3"},
+		{"-c3"},
+		{"--cnt", "3"},
+	}
+
+	for _, form := range forms {
+		args := append([]string{"krait", "test"}, form...)
+
+		root := NewFlagSet("root")
+		testFS := root.NewFlagSet("test", ContinueOnError)
+		testFS.CmdFunc = kraitTestFunction
+		count := testFS.OptionInt(optionAliases, 0, "What number will invoke 'The Count'")
+		_, err := root.Parse(args)
+
+		if err != nil || *count != want {
+			t.Fatalf("%q got: %d (%v) | want: %d", form, *count, err, want)
+		}
+	}
+}
+
+// TestTokenizeEndOfOptions ensure nothing after -- is treated as an option
+func TestTokenizeEndOfOptions(t *testing.T) {
+	want := []Argument{
+		{Type: ArgOptionAlias, Value: "--count", Position: 0},
+		{Type: ArgOptionArgument, Value: "1", Position: 1},
+		{Type: ArgEndOfOptions, Value: "--", Position: 2},
+		{Type: ArgBareArgument, Value: "-c", Position: 3},
+		{Type: ArgBareArgument, Value: "2", Position: 4},
+	}
+	args := []string{"-c", "1", "--", "-c", "2"}
+
+	root := NewFlagSet("root")
+	testFS := root.NewFlagSet("test")
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	got := testFS.tokenize(args)
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got: %v | want: %v", got, want)
+	}
+	if args[0] != "-c" {
+		t.Fatalf("input was modified: %q", args)
+	}
+}
+
+// TestOptionStringOptional ensure optional option-arguments must be attached with =
+func TestOptionStringOptional(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     string
+		wantArgs int
+	}{
+		{[]string{"krait", "test", "one"}, "never", 1},
+		{[]string{"krait", "test", "--color", "one"}, "auto", 1},
+		{[]string{"krait", "test", "--color=always", "one"}, "always", 1},
+	}
+
+	for _, tt := range tests {
+		root := NewFlagSet("root")
+		testFS := root.NewFlagSet("test")
+		testFS.CmdFunc = kraitTestFunction
+		testFS.OptionStringOptional([]string{"color"}, "never", "auto", "Colorize the output `WHEN`")
+		root.Parse(tt.args)
+
+		got, err := testFS.Options["color"].GetString()
+		if err != nil || got != tt.want || len(root.Args()) != tt.wantArgs {
+			t.Fatalf("%q got: %q %q (%v) | want: %q", tt.args, got, root.Args(), err, tt.want)
+		}
+	}
+}
+
+// TestOptionStringOptionalUsage ensure optional option-arguments are shown as --name[=PLACEHOLDER]
+func TestOptionStringOptionalUsage(t *testing.T) {
+	want := "--color[=WHEN]    Colorize the output WHEN (default: never)"
+	var buf bytes.Buffer
+
+	root := NewFlagSet("root")
+	testFS := root.NewFlagSet("test")
+	testFS.OptionStringOptional([]string{"color"}, "never", "auto", "Colorize the output `WHEN`")
+
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+	testFS.flagSet.Usage()
+
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("got: %q | want: %q", got, want)
+	}
+}
+
+// TestEndOfOptionsSubcommand ensure nothing after -- is treated as a subcommand
+func TestEndOfOptionsSubcommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     string
+		wantArgs []string
+	}{
+		{[]string{"myapp", "exec", "git", "status"}, "git", []string{"status"}},
+		{[]string{"myapp", "exec", "--", "git", "status"}, "exec", []string{"git", "status"}},
+		{[]string{"myapp", "exec", "--", "--verbose", "git"}, "exec", []string{"--verbose", "git"}},
+		{[]string{"myapp", "--", "exec"}, "", nil},
+	}
+
+	for _, tt := range tests {
+		root := NewFlagSet("myapp")
+		execFS := root.NewFlagSet("exec")
+		execFS.CmdFunc = kraitTestFunction
+		execFS.OptionBool([]string{"v", "verbose"}, false, "Verbose output")
+		gitFS := execFS.NewFlagSet("git")
+		gitFS.CmdFunc = kraitTestFunction
+
+		got, _ := root.Parse(tt.args)
+
+		if got != tt.want || strings.Join(root.Args(), " ") != strings.Join(tt.wantArgs, " ") {
+			t.Fatalf("%q got: %q %q | want: %q %q", tt.args, got, root.Args(), tt.want, tt.wantArgs)
+		}
+	}
+}
+
+// TestNArgs ensure the number of bare arguments is enforced
+func TestNArgs(t *testing.T) {
+	tests := []struct {
+		nargs   int
+		args    []string
+		wantErr bool
+	}{
+		{-1, []string{"krait", "test", "one", "two"}, false},
+		{0, []string{"krait", "test"}, false},
+		{0, []string{"krait", "test", "one"}, true},
+		{2, []string{"krait", "test", "one", "two"}, false},
+		{2, []string{"krait", "test", "one"}, true},
+	}
+
+	for _, tt := range tests {
+		root := NewFlagSet("krait")
+		testFS := root.NewFlagSet("test", ContinueOnError)
+		testFS.NArgs = tt.nargs
+		_, err := root.Parse(tt.args)
+
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("NArgs %d %q got error: %v | want error: %t", tt.nargs, tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// TestNamedArguments ensure named positional arguments are converted and assigned
+func TestNamedArguments(t *testing.T) {
+	args := []string{"krait", "copy", "src.txt", "3", "a", "b"}
+
+	root := NewFlagSet("krait")
+	copyFS := root.NewFlagSet("copy", ContinueOnError)
+	source := copyFS.Arg("SOURCE", "File to copy")
+	count := copyFS.ArgInt("COUNT", "Number of copies")
+	files := copyFS.ArgList("FILES", 1, -1, "Destination files")
+	_, err := root.Parse(args)
+
+	if err != nil || *source != "src.txt" || *count != 3 || strings.Join(*files, " ") != "a b" {
+		t.Fatalf("got: %q %d %q (%v)", *source, *count, *files, err)
+	}
+
+	_, err = root.Parse([]string{"krait", "copy", "src.txt", "three", "a"})
+	if err == nil || !strings.Contains(err.Error(), ErrorInvalidArgument) {
+		t.Fatalf("got: %v | want: %s", err, ErrorInvalidArgument)
+	}
+
+	_, err = root.Parse([]string{"krait", "copy", "src.txt", "3"})
+	if err == nil || !strings.Contains(err.Error(), "at least 3, got 2") {
+		t.Fatalf("got: %v | want: %s", err, ErrorArgumentCount)
+	}
+}
+
+// TestArgListRange ensure an impossible ArgList range panics when defined
+func TestArgListRange(t *testing.T) {
+	tests := []struct {
+		min  int
+		max  int
+		want string
+	}{
+		{-1, -1, "argument FILES has an invalid range of -1 to -1 values"},
+		{2, 1, "argument FILES has an invalid range of 2 to 1 values"},
+		{2, 2, "<nil>"},
+		{2, -1, "<nil>"},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if got := fmt.Sprint(recover()); got != tt.want {
+					t.Fatalf("ArgList(%d, %d) got: %s | want: %s", tt.min, tt.max, got, tt.want)
+				}
+			}()
+			root := NewFlagSet("krait")
+			root.NewFlagSet("copy").ArgList("FILES", tt.min, tt.max, "Destination files")
+		}()
+	}
+}
+
+// TestNamedArgumentsUsage ensure named arguments replace [ARGUMENTS] in the usage line
+func TestNamedArgumentsUsage(t *testing.T) {
+	want := "Usage: krait copy SOURCE [FILES...]\n"
+	var buf bytes.Buffer
+
+	root := NewFlagSet("krait")
+	copyFS := root.NewFlagSet("copy")
+	copyFS.Arg("SOURCE", "File to copy")
+	copyFS.ArgList("FILES", 0, -1, "Destination files")
+
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+	copyFS.flagSet.Usage()
+
+	if got := buf.String(); !strings.Contains(got, want) || !strings.Contains(got, "  SOURCE    File to copy\n") {
+		t.Fatalf("got: %q | want: %q", got, want)
+	}
+}
+
+// TestRequiredOptions ensure every missing required option is reported at once
+func TestRequiredOptions(t *testing.T) {
+	want := "missing required options: --count (-c), --name (-n)"
+
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test", ContinueOnError)
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	testFS.OptionStringOptional([]string{"n", "name"}, "", "", "Name to use")
+	testFS.OptionBool([]string{"v", "verbose"}, false, "Verbose output")
+	testFS.Require("count", "-n")
+
+	_, err := root.Parse([]string{"krait", "test", "-v"})
+	if err == nil || err.Error() != want {
+		t.Fatalf("got: %v | want: %s", err, want)
+	}
+
+	root = NewFlagSet("krait")
+	testFS = root.NewFlagSet("test", ContinueOnError)
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	testFS.Require("c")
+
+	if _, err = root.Parse([]string{"krait", "test", "--count=0"}); err != nil {
+		t.Fatalf("got: %v | want: nil", err)
+	}
+
+	defer func() {
+		want := `cannot require options ["verbose"] of the root "krait": root options are never parsed`
+		if got := fmt.Sprint(recover()); got != want {
+			t.Fatalf("got: %s | want: %s", got, want)
+		}
+	}()
+	root.OptionBool([]string{"verbose"}, false, "Verbose output")
+	root.Require("verbose")
+}
+
+// TestRequiredOptionsUsage ensure required options are marked in help output
+func TestRequiredOptionsUsage(t *testing.T) {
+	want := "-count    What number will invoke 'The Count' (required)\n"
+	var buf bytes.Buffer
+
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test")
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	testFS.Require("count")
+
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+	testFS.flagSet.Usage()
+
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Fatalf("got: %q | want: %q", got, want)
+	}
+}
+
+// TestOptionGroups ensure option group constraints are checked against
+// explicitly set options, whether set on the command line or by environment
+func TestOptionGroups(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  string
+		want string
+	}{
+		{[]string{"krait", "fetch", "--file=a"}, "", ""},
+		{[]string{"krait", "fetch", "--file=a", "--json", "--yaml=false"}, "", "mutually exclusive options: --json (-j), --yaml (-y)"},
+		{[]string{"krait", "fetch", "--json"}, "", "missing required option: one of --file (-f), --url"},
+		{[]string{"krait", "fetch", "--url=b", "-u=me"}, "", "options must be used together: --user (-u) set without --password (-p)"},
+		{[]string{"krait", "fetch", "--url=b", "-u=me", "-p="}, "", ""},
+		{[]string{"krait", "fetch", "--file=a", "--json"}, "true", "mutually exclusive options: --json (-j), --yaml (-y)"},
+		{[]string{"krait", "fetch", "--file=a"}, "false", ""},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("KRAIT_TEST_YAML", tt.env)
+		if tt.env == "" {
+			os.Unsetenv("KRAIT_TEST_YAML")
+		}
+
+		root := NewFlagSet("krait")
+		fetchFS := root.NewFlagSet("fetch", ContinueOnError)
+		fetchFS.OptionBool([]string{"j", "json"}, false, "JSON output")
+		fetchFS.OptionBool([]string{"y", "yaml"}, false, "YAML output")
+		fetchFS.OptionStringOptional([]string{"f", "file"}, "", "", "File to fetch")
+		fetchFS.OptionStringOptional([]string{"url"}, "", "", "URL to fetch")
+		fetchFS.OptionStringOptional([]string{"u", "user"}, "", "", "User")
+		fetchFS.OptionStringOptional([]string{"p", "password"}, "", "", "Password")
+		fetchFS.Env("yaml", "KRAIT_TEST_YAML")
+		fetchFS.MutuallyExclusive("json", "yaml")
+		fetchFS.OneRequired("file", "url")
+		fetchFS.RequiredTogether("user", "password")
+
+		_, err := root.Parse(tt.args)
+
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Fatalf("%q got: %q | want: %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestParseRepeatedly ensure Parse leaves its input alone and a tree can
+// parse several command lines in sequence
+func TestParseRepeatedly(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	root.DefaultSubCommand = "test"
+	testFS := root.NewFlagSet("test")
+	count := testFS.OptionInt([]string{"c", "count"}, 1, "What number will invoke 'The Count'")
+	labels := testFS.OptionMap([]string{"l", "label"}, "Labels")
+	files := testFS.ArgList("FILES", 0, -1, "Files")
+
+	input := []string{"krait", "test", "-c", "3", "--label=a=b", "one"}
+	if _, err := root.Parse(input); err != nil {
+		t.Fatal(err)
+	}
+	if *count != 3 || labels["a"] != "b" || fmt.Sprint(*files) != "[one]" || !root.Parsed() || !testFS.Parsed() {
+		t.Fatalf("got: %d %v %q parsed: %t", *count, labels, *files, root.Parsed())
+	}
+	if fmt.Sprint(input) != "[krait test -c 3 --label=a=b one]" {
+		t.Fatalf("input modified: %q", input)
+	}
+
+	input = []string{"krait"}
+	if _, err := root.Parse(input); err != nil {
+		t.Fatal(err)
+	}
+	if *count != 1 || len(labels) != 0 || len(*files) != 0 || testFS.optionIsSet("count") {
+		t.Fatalf("got: %d %v %q set: %t", *count, labels, *files, testFS.optionIsSet("count"))
+	}
+	if len(input) != 1 {
+		t.Fatalf("input modified: %q", input)
+	}
+
+	root.Reset()
+	if root.Parsed() || root.SubCommand() != "" || len(root.Args()) != 0 {
+		t.Fatalf("got parsed: %t subcommand: %q args: %q", root.Parsed(), root.SubCommand(), root.Args())
+	}
+}
+
+// listValue is an accumulating flag.Value used to test ResettableValue
+type listValue []string
+
+func (lv *listValue) Reset()             { *lv = nil }
+func (lv *listValue) Set(s string) error { *lv = append(*lv, strings.Split(s, ",")...); return nil }
+func (lv *listValue) String() string     { return strings.Join(*lv, ",") }
+
+// TestResetKeepsOutput ensure Reset keeps the output of a FlagSet and empties
+// accumulating values
+func TestResetKeepsOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	root := NewFlagSet("krait", ContinueOnError)
+	testFS := root.NewFlagSet("test")
+	tags := &listValue{}
+	testFS.OptionValue([]string{"t", "tag"}, tags, "Tags")
+	testFS.flagSet.SetOutput(&buf)
+
+	for _, args := range [][]string{{"krait", "test", "-t", "a", "-t", "b"}, {"krait", "test", "-t", "c"}} {
+		if _, err := root.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := tags.String(); got != "c" {
+		t.Fatalf("got: %s | want: c", got)
+	}
+
+	root.Parse([]string{"krait", "test", "--bogus"})
+	if want := "unknown option \"--bogus\""; !strings.Contains(buf.String(), want) {
+		t.Fatalf("got: %q | want the error in the FlagSet output after Reset", buf.String())
+	}
+}
+
+// TestOptionLookup ensure options are found by any alias and expose their definition
+func TestOptionLookup(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	testFS := root.NewFlagSet("test")
+	testFS.OptionInt([]string{"c", "count", "cnt"}, 3, "What number will invoke 'The Count'")
+
+	for _, alias := range []string{"c", "-c", "cnt", "--cnt", "count", "-count", "--count"} {
+		o, ok := testFS.Option(alias)
+		if !ok || o.Name() != "count" {
+			t.Fatalf("%q got: %q (%t) | want: %q", alias, o.Name(), ok, "count")
+		}
+	}
+	if _, ok := testFS.Option("x"); ok {
+		t.Fatal("found undefined option \"x\"")
+	}
+
+	o, _ := testFS.Option("c")
+	if got := fmt.Sprint(o.Aliases()); got != "[count c cnt]" {
+		t.Errorf("Aliases() got: %s | want: %s", got, "[count c cnt]")
+	}
+	if o.Usage() != "What number will invoke 'The Count'" || o.Default() != "3" {
+		t.Errorf("got usage: %q default: %q", o.Usage(), o.Default())
+	}
+}
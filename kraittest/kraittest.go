@@ -0,0 +1,159 @@
+// Package kraittest provides helpers for testing command line interfaces
+// built on Krait. Run parses an argv with a root FlagSet, capturing its
+// output and any exit request, and Golden compares output with golden files.
+//
+// Run redirects the process wide os.Stdout and os.Stderr while parsing, so it
+// must not be used from tests calling t.Parallel().
+package kraittest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/runeimp/krait"
+)
+
+// update rewrites golden files instead of comparing them, e.g.
+// go test ./... -kraittest.update. The name is namespaced so it never clashes
+// with an -update flag of the package under test.
+var update = flag.Bool("kraittest.update", false, "update kraittest golden files")
+
+// exitRequest is the panic value used to unwind Parse when the root FlagSet
+// asks to exit
+type exitRequest struct {
+	code int
+}
+
+// ParseResult is what FlagSet.Parse returned
+type ParseResult struct {
+	Args       []string // Bare arguments of the active subcommand
+	Err        error    // Error returned by Parse or the error Parse panicked with
+	Subcommand string   // Active subcommand
+}
+
+// Result is the outcome of Run
+type Result struct {
+	Code   int  // Exit code requested by the FlagSet or mapped from the error
+	Exited bool // If the FlagSet asked to exit
+	Parse  ParseResult
+	Stderr string
+	Stdout string
+}
+
+// Run parses argv with the root FlagSet. argv includes the command name, e.g.
+// Run(root, "myapp", "help"). Standard output and standard error are
+// captured while parsing, and exit requests made under ExitOnError are
+// intercepted instead of calling os.Exit. The capture swaps os.Stdout and
+// os.Stderr for the whole process, so Run is not safe for parallel tests.
+func Run(root *krait.FlagSet, argv ...string) (result Result) {
+	exit := root.Exit
+	defer func() { root.Exit = exit }()
+	root.Exit = func(code int) {
+		panic(exitRequest{code: code})
+	}
+
+	stdout, stderr, restore := capture()
+	func() {
+		defer func() {
+			switch r := recover().(type) {
+			case nil:
+			case exitRequest:
+				result.Code = r.code
+				result.Exited = true
+			case error:
+				// PanicOnError
+				result.Parse.Err = r
+				result.Code = root.ExitCode(r)
+			default:
+				restore()
+				panic(r)
+			}
+		}()
+
+		result.Parse.Subcommand, result.Parse.Err = root.Parse(argv)
+		result.Code = root.ExitCode(result.Parse.Err)
+	}()
+	restore()
+
+	result.Parse.Args = root.Args()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	return result
+}
+
+// Golden compares got with the golden file testdata/<name>.golden. Running
+// the tests with -kraittest.update writes got to the golden file instead.
+func Golden(t testing.TB, name string, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist, run the tests with -kraittest.update to create it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Fatalf("output does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+// capture redirects os.Stdout and os.Stderr of the whole process until
+// restore is called
+func capture() (stdout *bytes.Buffer, stderr *bytes.Buffer, restore func()) {
+	stdout = new(bytes.Buffer)
+	stderr = new(bytes.Buffer)
+
+	var wg sync.WaitGroup
+	redirect := func(f **os.File, buf *bytes.Buffer) func() {
+		r, w, err := os.Pipe()
+		if err != nil {
+			panic(err)
+		}
+		original := *f
+		*f = w
+
+		wg.Add(1)
+		go func() {
+			io.Copy(buf, r)
+			r.Close()
+			wg.Done()
+		}()
+
+		return func() {
+			*f = original
+			w.Close()
+		}
+	}
+
+	restoreStdout := redirect(&os.Stdout, stdout)
+	restoreStderr := redirect(&os.Stderr, stderr)
+
+	var once sync.Once
+	restore = func() {
+		once.Do(func() {
+			restoreStdout()
+			restoreStderr()
+			wg.Wait()
+		})
+	}
+	return stdout, stderr, restore
+}
@@ -0,0 +1,58 @@
+package kraittest
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/runeimp/krait"
+)
+
+// packageUpdate is the common golden file flag of packages under test, defining it
+// ensures kraittest never registers a clashing flag
+var packageUpdate = flag.Bool("update", false, "update the golden files of the package under test")
+
+func newRoot(errHandler ...flag.ErrorHandling) *krait.FlagSet {
+	root := krait.NewFlagSet("myapp", errHandler...)
+	root.AppLabel = "MyApp v0.1.0"
+
+	testFS := root.NewFlagSet("test")
+	testFS.Summery = "Tests the basic usage of Krait"
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+
+	return root
+}
+
+// TestRunHelp ensure help output can be tested without exiting
+func TestRunHelp(t *testing.T) {
+	result := Run(newRoot(), "myapp", "help")
+
+	if !result.Exited || result.Code != krait.ExitOK {
+		t.Fatalf("got exited: %t with code %d | want: exited with code %d", result.Exited, result.Code, krait.ExitOK)
+	}
+	Golden(t, "help", result.Stdout+result.Stderr)
+}
+
+// TestRunVersion ensure version output and exit requests are captured
+func TestRunVersion(t *testing.T) {
+	result := Run(newRoot(krait.ContinueOnError), "myapp", "version")
+
+	if result.Exited || !errors.Is(result.Parse.Err, krait.ErrVersionRequested) {
+		t.Fatalf("got exited: %t with error %v | want: %v", result.Exited, result.Parse.Err, krait.ErrVersionRequested)
+	}
+	if got, want := result.Stderr, "MyApp v0.1.0\n"; got != want {
+		t.Fatalf("got: %q | want: %q", got, want)
+	}
+}
+
+// TestRunError ensure an exit request carries the mapped exit code
+func TestRunError(t *testing.T) {
+	result := Run(newRoot(), "myapp", "tset")
+
+	if !result.Exited || result.Code != krait.ExitUsage {
+		t.Fatalf("got exited: %t with code %d | want: exited with code %d", result.Exited, result.Code, krait.ExitUsage)
+	}
+	if result.Stderr == "" {
+		t.Fatal("expected the error on stderr")
+	}
+}
@@ -0,0 +1,152 @@
+package krait
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Marker shown in the COMMAND SUMMERY for external plugins
+const pluginMarker = "[plugin]"
+
+// Environment variables set for external plugins
+const (
+	EnvPluginAppLabel   = "KRAIT_APP_LABEL"  // AppLabel of the root FlagSet
+	EnvPluginCommand    = "KRAIT_COMMAND"    // Name of the root command, e.g. myapp
+	EnvPluginExecutable = "KRAIT_EXECUTABLE" // Path of the executable that ran the plugin
+	EnvPluginName       = "KRAIT_PLUGIN"     // Subcommand the plugin was run for, e.g. deploy
+)
+
+// PluginError reports an external plugin that could not be run or exited
+// with a non-zero status. ExitCode returns the plugin exit status.
+type PluginError struct {
+	Code   int    // Exit status of the plugin
+	Err    error  // Error from running the plugin
+	Path   string // Path of the plugin executable
+	Plugin string // Subcommand the plugin was run for
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("plugin %q: %v", e.Plugin, e.Err)
+}
+
+func (e *PluginError) Unwrap() error {
+	return e.Err
+}
+
+// EnablePlugins makes subcommands that are not registered run an external
+// executable named <root>-<subcommand>, such as myapp-deploy. The dirs are
+// searched before $PATH.
+func (fs *FlagSet) EnablePlugins(dirs ...string) {
+	rfs := fs.getRoot()
+	rfs.pluginDirs = append(rfs.pluginDirs, dirs...)
+	rfs.pluginsEnabled = true
+}
+
+// lookPlugin returns the path of the plugin executable for the subcommand
+// name if external plugins are enabled and name is not a registered
+// subcommand
+func (fs *FlagSet) lookPlugin(name string) (path string, ok bool) {
+	if !fs.pluginsEnabled || name == "" || strings.HasPrefix(name, "-") || strings.ContainsRune(name, filepath.Separator) {
+		return path, false
+	}
+	if _, registered := fs.argIsSubcommand(name); registered {
+		return path, false
+	}
+
+	for _, dir := range fs.pluginSearchPath() {
+		// A bare name such as Join(".", name) would make LookPath search $PATH
+		candidate := filepath.Join(dir, fs.cmd+"-"+name)
+		if !strings.ContainsRune(candidate, filepath.Separator) {
+			candidate = "." + string(filepath.Separator) + candidate
+		}
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, true
+		}
+	}
+	return path, false
+}
+
+// pluginSearchPath returns the plugin dirs followed by the $PATH dirs. Empty
+// $PATH entries are skipped rather than searching the working directory, as
+// exec.LookPath does from Go 1.19.
+func (fs *FlagSet) pluginSearchPath() (dirs []string) {
+	dirs = append(dirs, fs.pluginDirs...)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// plugins returns the path of every external plugin found keyed by its
+// subcommand name. Registered subcommands hide plugins of the same name and
+// earlier dirs hide later ones.
+func (fs *FlagSet) plugins() (plugins map[string]string) {
+	plugins = make(map[string]string)
+	if !fs.pluginsEnabled {
+		return plugins
+	}
+
+	prefix := fs.cmd + "-"
+	for _, dir := range fs.pluginSearchPath() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			name = strings.TrimPrefix(name, prefix)
+			if _, found := plugins[name]; found {
+				continue
+			}
+			if path, ok := fs.lookPlugin(name); ok {
+				plugins[name] = path
+			}
+		}
+	}
+	return plugins
+}
+
+// runPlugin runs the plugin executable with args, passing through standard
+// input, output and error. The plugin exit status is the exit code under
+// ExitOnError and a *PluginError otherwise.
+func (fs *FlagSet) runPlugin(name string, path string, args []string) (err error) {
+	executable, _ := os.Executable()
+
+	cmd := exec.Command(path, args...)
+	cmd.Env = append(os.Environ(),
+		EnvPluginAppLabel+"="+fs.AppLabel,
+		EnvPluginCommand+"="+fs.cmd,
+		EnvPluginExecutable+"="+executable,
+		EnvPluginName+"="+name,
+	)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err = cmd.Run(); err != nil {
+		perr := &PluginError{Code: ExitFailure, Err: err, Path: path, Plugin: name}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			perr.Code = exitErr.ExitCode()
+		}
+		return fs.terminate(perr)
+	}
+
+	if fs.ErrorHandling() == ExitOnError {
+		fs.Exit(ExitOK)
+	}
+	return nil
+}
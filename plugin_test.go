@@ -0,0 +1,94 @@
+package krait
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestPlugins ensure unregistered subcommands run external plugins and the
+// plugins are listed in help
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test uses a shell script")
+	}
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$KRAIT_PLUGIN $KRAIT_COMMAND $2\" > \"$1\"\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(dir, "krait-deploy"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", "")
+
+	root := NewFlagSet("krait", ContinueOnError)
+	root.EnablePlugins(dir)
+
+	out := filepath.Join(dir, "out")
+	subcmd, err := root.Parse([]string{"krait", "deploy", out, "prod"})
+
+	var perr *PluginError
+	if subcmd != "deploy" || !errors.As(err, &perr) || root.ExitCode(err) != 3 {
+		t.Fatalf("got: %q %v exit code %d | want: deploy with exit code 3", subcmd, err, root.ExitCode(err))
+	}
+	if got, _ := os.ReadFile(out); string(got) != "deploy krait prod\n" {
+		t.Fatalf("got plugin output: %q", got)
+	}
+
+	var buf bytes.Buffer
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+
+	root.Parse([]string{"krait", "help"})
+	if want := "  deploy           [plugin] krait-deploy\n"; !strings.Contains(buf.String(), want) {
+		t.Fatalf("got:\n%s\nwant line: %q", buf.String(), want)
+	}
+}
+
+// TestPluginSearchPath ensure plugin dirs are searched before $PATH, even
+// when given relative to the working directory, and empty $PATH entries are
+// skipped
+func TestPluginSearchPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test uses a shell script")
+	}
+
+	pluginDir := t.TempDir()
+	pathDir := t.TempDir()
+	for _, dir := range []string{pluginDir, pathDir} {
+		if err := os.WriteFile(filepath.Join(dir, "krait-deploy"), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", string(filepath.ListSeparator)+pathDir)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(pluginDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		dirs []string
+		want string
+	}{
+		{[]string{pluginDir}, filepath.Join(pluginDir, "krait-deploy")},
+		{[]string{"."}, "." + string(filepath.Separator) + "krait-deploy"},
+		{nil, filepath.Join(pathDir, "krait-deploy")},
+	}
+
+	for _, tt := range tests {
+		root := NewFlagSet("krait", ContinueOnError)
+		root.EnablePlugins(tt.dirs...)
+		if got, ok := root.lookPlugin("deploy"); !ok || got != tt.want {
+			t.Errorf("%q got: %q %t | want: %q", tt.dirs, got, ok, tt.want)
+		}
+	}
+}
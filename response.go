@@ -0,0 +1,102 @@
+package krait
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResponseFileFormat is how the arguments in a response file are written
+type ResponseFileFormat int
+
+// Response file formats for FlagSet.ResponseFiles
+const (
+	ResponseFilesOff  ResponseFileFormat = iota // @file arguments are not expanded (default)
+	ResponseFileLines                           // One argument per line, blank lines are ignored
+	ResponseFileShell                           // Shell-quoted arguments separated by whitespace, lines starting with # are comments
+)
+
+// expandResponseFiles replaces every @file argument with the arguments read
+// from file. Response files may include other response files relative to
+// their own directory. @@ escapes a literal @ and nothing following -- is
+// expanded.
+func (fs *FlagSet) expandResponseFiles(args []string) (expanded []string, err error) {
+	if fs.ResponseFiles == ResponseFilesOff {
+		return args, nil
+	}
+
+	expanded = []string{args[0]}
+	endOfOptions := false
+
+	for i, arg := range args[1:] {
+		if expanded, err = fs.expandResponseFile(expanded, arg, i+1, "", nil, &endOfOptions); err != nil {
+			return args, err
+		}
+	}
+
+	return expanded, nil
+}
+
+// expandResponseFile appends arg, or the arguments of the response file it
+// names, to expanded. The stack holds the response files being read to
+// detect cycles.
+func (fs *FlagSet) expandResponseFile(expanded []string, arg string, position int, dir string, stack []string, endOfOptions *bool) ([]string, error) {
+	switch {
+	case *endOfOptions || !strings.HasPrefix(arg, "@") || arg == "@":
+		if arg == "--" {
+			*endOfOptions = true
+		}
+		return append(expanded, arg), nil
+	case strings.HasPrefix(arg, "@@"):
+		return append(expanded, arg[1:]), nil
+	}
+
+	path := arg[1:]
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	for _, seen := range stack {
+		if seen == path {
+			chain := append(stack, path)
+			return expanded, fs.parseError(ErrResponseFile, arg, position, "%s %s: cycle %s", ErrorResponseFile, arg, strings.Join(chain, " -> "))
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return expanded, fs.parseError(ErrResponseFile, arg, position, "%s %s: %v", ErrorResponseFile, arg, err)
+	}
+
+	var fileArgs []string
+	switch fs.ResponseFiles {
+	case ResponseFileLines:
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimRight(line, "\r"); line != "" {
+				fileArgs = append(fileArgs, line)
+			}
+		}
+	case ResponseFileShell:
+		var lines []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+				lines = append(lines, strings.TrimRight(line, "\r"))
+			}
+		}
+		if fileArgs, err = SplitCommandLine(strings.Join(lines, "\n")); err != nil {
+			return expanded, fs.parseError(ErrResponseFile, arg, position, "%s %s: %v", ErrorResponseFile, arg, err)
+		}
+	}
+
+	stack = append(stack, path)
+	for _, fileArg := range fileArgs {
+		if expanded, err = fs.expandResponseFile(expanded, fileArg, position, filepath.Dir(path), stack, endOfOptions); err != nil {
+			return expanded, err
+		}
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,57 @@
+package krait
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestResponseFiles ensure @file arguments expand before subcommand
+// resolution with nesting, cycle detection and escapes
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"args.txt":   "# Shell-quoted\ntest -c 3 'one two'\n@nested.txt\n",
+		"nested.txt": "@@literal \"three\"",
+		"cycle.txt":  "test @cycle.txt",
+		"lines.txt":  "test\none two\n\n@@literal\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		format ResponseFileFormat
+		args   []string
+		want   string
+	}{
+		{ResponseFileShell, []string{"krait", "@" + filepath.Join(dir, "args.txt"), "four", "--", "@five"}, `3 ["one two" "@literal" "three" "four" "--" "@five"]`},
+		{ResponseFileLines, []string{"krait", "@" + filepath.Join(dir, "lines.txt")}, `0 ["one two" "@literal"]`},
+		{ResponseFilesOff, []string{"krait", "test", "@" + filepath.Join(dir, "lines.txt")}, fmt.Sprintf("0 [%q]", "@"+filepath.Join(dir, "lines.txt"))},
+	}
+
+	for _, tt := range tests {
+		root := NewFlagSet("krait", ContinueOnError)
+		root.ResponseFiles = tt.format
+		testFS := root.NewFlagSet("test")
+		count := testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+
+		if _, err := root.Parse(tt.args); err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+		if got := fmt.Sprintf("%d %q", *count, root.Args()); got != tt.want {
+			t.Fatalf("%q got: %s | want: %s", tt.args, got, tt.want)
+		}
+	}
+
+	root := NewFlagSet("krait", ContinueOnError)
+	root.ResponseFiles = ResponseFileShell
+	root.NewFlagSet("test")
+	if _, err := root.Parse([]string{"krait", "@" + filepath.Join(dir, "cycle.txt")}); !errors.Is(err, ErrResponseFile) {
+		t.Fatalf("got: %v | want: %v", err, ErrResponseFile)
+	}
+}
@@ -0,0 +1,316 @@
+package krait
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Commands handled by the Shell itself rather than the FlagSet tree, a
+// registered subcommand of the same name takes priority
+var shellCommands = []string{"exit", "history", "quit"}
+
+// LineReader reads a line of input for the Shell. The default reader has no
+// history or completion, a LineReader backed by a line editor may also
+// implement Completer and HistoryAdder to get them from the Shell.
+type LineReader interface {
+	// ReadLine displays the prompt and returns the next line without the
+	// trailing newline. io.EOF ends the Shell.
+	ReadLine(prompt string) (line string, err error)
+}
+
+// Completer is implemented by a LineReader offering tab completion. Run
+// hands it Shell.Complete before reading the first line.
+type Completer interface {
+	SetCompleter(complete func(line string) (candidates []string))
+}
+
+// HistoryAdder is implemented by a LineReader offering history. Run adds
+// each line entered to it as well as to Shell.History.
+type HistoryAdder interface {
+	AddHistory(line string)
+}
+
+// Shell is a line oriented REPL over a FlagSet tree. Each line is split with
+// shell-like quoting and parsed as if it followed the root command name on
+// the command line.
+type Shell struct {
+	History   []string   // Lines entered so far, oldest first
+	Prompt    string     // Prompt displayed for each line, "<root>> " by default
+	Reader    LineReader // Source of input lines, standard input by default
+	requested bool
+	root      *FlagSet
+}
+
+// stdinReader is the default LineReader
+type stdinReader struct {
+	r *bufio.Reader
+}
+
+func (sr *stdinReader) ReadLine(prompt string) (line string, err error) {
+	fmt.Fprint(os.Stdout, prompt)
+
+	line, err = sr.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// EnableShell adds the built-in "shell" subcommand to the root FlagSet and
+// returns its Shell for configuration. Parse only records that the shell was
+// requested, the REPL is run once Parse returns so it never nests inside the
+// outer Parse:
+//
+//	sh := cli.EnableShell()
+//	if _, err := cli.Parse(); err == nil && sh.Requested() {
+//		err = sh.Run()
+//	}
+func (fs *FlagSet) EnableShell() (sh *Shell) {
+	rfs := fs.getRoot()
+	sh = &Shell{
+		Prompt: rfs.cmd + "> ",
+		root:   rfs,
+	}
+
+	shellFS := rfs.NewFlagSet("shell")
+	shellFS.CmdFunc = func(fs *FlagSet, args ...string) {
+		sh.requested = true
+	}
+	shellFS.NArgs = 0
+	shellFS.Summery = "Starts an interactive shell"
+
+	return sh
+}
+
+// Complete returns the completion candidates for the last word of line from
+// the subcommand and option registry
+func (sh *Shell) Complete(line string) (candidates []string) {
+	words, err := SplitCommandLine(line)
+	if err != nil {
+		words = strings.Fields(line)
+	}
+
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	// Drill down through the complete subcommand words
+	fs := sh.root
+	for _, word := range words {
+		name, ok := fs.argIsSubcommand(word)
+		if !ok {
+			break
+		}
+		fs = fs.subcommands[name]
+	}
+
+	var names []string
+	if strings.HasPrefix(partial, "-") {
+		for alias, canonical := range fs.optionAliases {
+			if fs.optionVisible(canonical) {
+				names = append(names, alias)
+			}
+		}
+	} else {
+		names = fs.visibleSubcommandNames()
+		if fs == sh.root && len(words) == 0 {
+			for _, name := range shellCommands {
+				if _, registered := fs.argIsSubcommand(name); !registered {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, partial) {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+
+	return candidates
+}
+
+// Requested returns true if Parse ran the shell subcommand since the Shell
+// last ran or false otherwise
+func (sh *Shell) Requested() bool {
+	return sh.requested
+}
+
+// Run reads and runs lines until the input ends or exit or quit is entered.
+// Option state is reset between lines and errors never end the Shell. Run
+// must not be called from within Parse, such as from a CmdFunc.
+func (sh *Shell) Run() (err error) {
+	sh.requested = false
+	if sh.Reader == nil {
+		sh.Reader = &stdinReader{r: bufio.NewReader(os.Stdin)}
+	}
+	if c, ok := sh.Reader.(Completer); ok {
+		c.SetCompleter(sh.Complete)
+	}
+	history, _ := sh.Reader.(HistoryAdder)
+
+	for {
+		var line string
+		line, err = sh.Reader.ReadLine(sh.Prompt)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		sh.History = append(sh.History, line)
+		if history != nil {
+			history.AddHistory(line)
+		}
+
+		args, splitErr := SplitCommandLine(line)
+		if splitErr != nil {
+			fmt.Fprintln(os.Stderr, splitErr)
+			continue
+		}
+
+		if args[0] == "shell" {
+			fmt.Fprintln(os.Stderr, "already in the shell")
+			continue
+		}
+		if _, registered := sh.root.argIsSubcommand(args[0]); !registered {
+			switch args[0] {
+			case "exit", "quit":
+				return nil
+			case "history":
+				for i, entry := range sh.History {
+					fmt.Printf("%5d  %s\n", i+1, entry)
+				}
+				continue
+			}
+		}
+
+		sh.run(args)
+	}
+}
+
+// run parses a single line of the Shell. Exit requests and PanicOnError
+// panics only end the line, not the Shell.
+func (sh *Shell) run(args []string) {
+	type exitRequest struct{}
+
+	exit := sh.root.Exit
+	defer func() { sh.root.Exit = exit }()
+	sh.root.Exit = func(code int) {
+		panic(exitRequest{})
+	}
+
+	defer func() {
+		sh.root.exitErr = nil
+
+		switch r := recover().(type) {
+		case nil, exitRequest:
+		case error:
+			if !reportedError(r) {
+				panic(r)
+			}
+		default:
+			panic(r)
+		}
+	}()
+
+	sh.root.Parse(append([]string{sh.root.cmd}, args...))
+}
+
+// reportedError returns true if err is one krait panics with under
+// PanicOnError after reporting it, false for anything else such as a
+// runtime error in a CmdFunc
+func reportedError(err error) bool {
+	var (
+		parseErr      *ParseError
+		pluginErr     *PluginError
+		runtimeErr    runtime.Error
+		validationErr *ValidationError
+	)
+
+	switch {
+	case errors.As(err, &runtimeErr):
+		return false
+	case errors.As(err, &parseErr), errors.As(err, &pluginErr), errors.As(err, &validationErr):
+		return true
+	}
+	return errors.Is(err, ErrHelpRequested) || errors.Is(err, ErrVersionRequested)
+}
+
+// SplitCommandLine splits a line into arguments the way a POSIX shell would,
+// honoring single quotes, double quotes and backslash escapes. Variables,
+// globs and other expansions are not supported.
+func SplitCommandLine(line string) (args []string, err error) {
+	var (
+		arg    strings.Builder
+		inArg  bool
+		quote  rune
+		escape bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escape:
+			// Within double quotes a backslash only escapes a few characters
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escape = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escape {
+		return args, errors.New("unterminated backslash escape")
+	}
+	if quote != 0 {
+		return args, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
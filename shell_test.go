@@ -0,0 +1,171 @@
+package krait
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// scriptReader is a LineReader returning a fixed set of lines
+type scriptReader []string
+
+func (sr *scriptReader) ReadLine(prompt string) (line string, err error) {
+	if len(*sr) == 0 {
+		return "", io.EOF
+	}
+	line, *sr = (*sr)[0], (*sr)[1:]
+	return line, nil
+}
+
+// editorReader is a scriptReader with completion and history like a line
+// editor
+type editorReader struct {
+	scriptReader
+	complete func(line string) (candidates []string)
+	history  []string
+}
+
+func (er *editorReader) AddHistory(line string) {
+	er.history = append(er.history, line)
+}
+
+func (er *editorReader) SetCompleter(complete func(line string) (candidates []string)) {
+	er.complete = complete
+}
+
+// TestSplitCommandLine ensure lines are split with shell-like quoting
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`test -c 3  one`, `["test" "-c" "3" "one"]`},
+		{`say 'single "quoted"' "double \"quoted\" \n"`, `["say" "single \"quoted\"" "double \"quoted\" \\n"]`},
+		{`a\ b '' c`, `["a b" "" "c"]`},
+		{`say "unterminated`, `error: unterminated " quote`},
+	}
+
+	for _, tt := range tests {
+		args, err := SplitCommandLine(tt.line)
+		got := fmt.Sprintf("%q", args)
+		if err != nil {
+			got = "error: " + err.Error()
+		}
+		if got != tt.want {
+			t.Fatalf("%s got: %s | want: %s", tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestShell ensure lines are dispatched with option state reset between them
+func TestShell(t *testing.T) {
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test")
+	count := testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+
+	var got []string
+	testFS.CmdFunc = func(fs *FlagSet, args ...string) {
+		got = append(got, fmt.Sprintf("%d %q", *count, fs.Args()))
+	}
+
+	sh := root.EnableShell()
+	reader := &scriptReader{"test -c 3 'one two'", "tset", "test three", "exit", "test"}
+	sh.Reader = reader
+	if _, err := root.Parse([]string{"krait", "shell"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The REPL only runs once the outer Parse returned
+	if !sh.Requested() || len(*reader) != 5 || !root.Parsed() || root.SubCommand() != "shell" {
+		t.Fatalf("got requested: %t | lines read: %d | parsed: %t | subcommand: %q", sh.Requested(), 5-len(*reader), root.Parsed(), root.SubCommand())
+	}
+	if err := sh.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if sh.Requested() {
+		t.Fatal("got requested after Run")
+	}
+
+	if want := `[3 ["one two"] 0 ["three"]]`; fmt.Sprint(got) != want {
+		t.Fatalf("got: %s | want: %s", got, want)
+	}
+	if len(sh.History) != 4 {
+		t.Fatalf("got history: %q", sh.History)
+	}
+}
+
+// TestShellCommands ensure registered subcommands take priority over the
+// built-in shell commands and only errors krait reported are survived
+func TestShellCommands(t *testing.T) {
+	root := NewFlagSet("krait", PanicOnError)
+	var got []string
+	historyFS := root.NewFlagSet("history")
+	historyFS.CmdFunc = func(fs *FlagSet, args ...string) {
+		got = append(got, "history "+fmt.Sprint(args))
+	}
+	crashFS := root.NewFlagSet("crash")
+	crashFS.CmdFunc = func(fs *FlagSet, args ...string) {
+		var m map[string]int
+		m["boom"]++
+	}
+
+	sh := root.EnableShell()
+	sh.Reader = &scriptReader{"history 1", "tset", "exit"}
+	if err := sh.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[history [1]]"; fmt.Sprint(got) != want {
+		t.Fatalf("got: %s | want: %s", got, want)
+	}
+	if completions := fmt.Sprint(sh.Complete("h")); completions != "[help history hlp]" {
+		t.Fatalf("got completions: %s | want: [help history hlp]", completions)
+	}
+
+	defer func() {
+		if _, ok := recover().(runtime.Error); !ok {
+			t.Fatal("got: runtime error swallowed by the shell")
+		}
+	}()
+	sh.Reader = &scriptReader{"crash", "exit"}
+	sh.Run()
+}
+
+// TestShellComplete ensure completions come from the subcommand and option
+// registry
+func TestShellComplete(t *testing.T) {
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test")
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	testFS.NewFlagSet("one")
+	sh := root.EnableShell()
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"", "[exit help history hlp quit shell test ver version]"},
+		{"he", "[help]"},
+		{"test ", "[one]"},
+		{"test --c", "[--count]"},
+		{"test -", "[--count -c]"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(sh.Complete(tt.line)); got != tt.want {
+			t.Fatalf("%q got: %s | want: %s", tt.line, got, tt.want)
+		}
+	}
+
+	reader := &editorReader{scriptReader: scriptReader{"test -c 1", "history"}}
+	sh.Reader = reader
+	if err := sh.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if reader.complete == nil || fmt.Sprint(reader.complete("test --c")) != "[--count]" {
+		t.Fatal("got no completer handed to the reader")
+	}
+	if got := fmt.Sprint(reader.history); got != "[test -c 1 history]" {
+		t.Fatalf("got history: %s | want: [test -c 1 history]", got)
+	}
+}
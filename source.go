@@ -0,0 +1,125 @@
+package krait
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Source describes where the value of an option came from
+type Source struct {
+	Kind     string // SourceDefault, SourceConfig, SourceEnv or SourceCommandLine
+	Name     string // Config file path or environment variable name
+	Position int    // Index in the command line given to Parse, -1 if not from the command line
+}
+
+func (s Source) String() string {
+	if s.Name != "" {
+		return s.Kind + " " + s.Name
+	}
+	return s.Kind
+}
+
+// configValue is an option value from a config file
+type configValue struct {
+	path  string
+	value string
+}
+
+// optionState is shared by every copy of an Option to track how it was set
+type optionState struct {
+	set    bool
+	source Source
+}
+
+// ApplyConfig sets options from a config file that are not set on the
+// command line or by their environment variable. The values are keyed by
+// any option alias and applied each time the command is parsed.
+func (fs *FlagSet) ApplyConfig(path string, values map[string]string) (err error) {
+	for alias, value := range values {
+		name, ok := fs.optionName(alias)
+		if !ok {
+			return fmt.Errorf("%s: %s %q for %q", path, ErrorUnknownOption, alias, fs.cmd)
+		}
+		if fs.config == nil {
+			fs.config = make(map[string]configValue)
+		}
+		fs.config[name] = configValue{path: path, value: value}
+	}
+	return nil
+}
+
+// applyConfig sets the options of the active command chain that are still
+// unset from their config file values
+func (fs *FlagSet) applyConfig() (err error) {
+	for _, cfs := range fs.getCommandChain() {
+		names := []string{}
+		for name := range cfs.config {
+			if !cfs.optionIsSet(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			config := cfs.config[name]
+			source := Source{Kind: SourceConfig, Name: config.path, Position: -1}
+			if err = cfs.optionSet(name, config.value, source); err != nil {
+				return &ValidationError{Option: cfs.optionDisplayName(name), Source: source.String(), Value: config.value, Err: err}
+			}
+		}
+	}
+
+	return err
+}
+
+// IsSet returns true if the option was set on the command line, by its
+// environment variable or from a config file, even if to its default value
+func (o Option) IsSet() bool {
+	return o.state != nil && o.state.set
+}
+
+// optionSet sets the value of an option and records its source
+func (fs *FlagSet) optionSet(name string, value string, source Source) (err error) {
+	f := fs.flagSet.Lookup(name)
+	if m, ok := f.Value.(mapValue); ok && !fs.optionIsSet(name) {
+		// Values from a new source replace the default entries
+		for key := range m {
+			delete(m, key)
+		}
+	}
+	if value != "" || f.Value.String() != "" {
+		if err = fs.flagSet.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	if o := fs.Options[name]; o.state != nil {
+		o.state.set = true
+		o.state.source = source
+	}
+	return nil
+}
+
+// Source returns where the value of the option came from
+func (o Option) Source() Source {
+	if !o.IsSet() {
+		return Source{Kind: SourceDefault, Position: -1}
+	}
+	return o.state.source
+}
+
+// VisitSet calls fn for each option of the FlagSet that was set, in
+// lexicographical order of the option names
+func (fs *FlagSet) VisitSet(fn func(name string, o Option)) {
+	names := make([]string, 0, len(fs.Options))
+	for name, o := range fs.Options {
+		if o.IsSet() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fn(name, fs.Options[name])
+	}
+}
@@ -0,0 +1,60 @@
+package krait
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestOptionSource ensure options record if and where they were set
+func TestOptionSource(t *testing.T) {
+	t.Setenv("KRAIT_TEST_LEVEL", "debug")
+	args := []string{"krait", "test", "--count", "0", "-v"}
+
+	root := NewFlagSet("krait", ContinueOnError)
+	testFS := root.NewFlagSet("test")
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	testFS.OptionString([]string{"level"}, "info", "Log level")
+	testFS.Env("level", "KRAIT_TEST_LEVEL")
+	testFS.OptionString([]string{"o", "output"}, "text", "Output format")
+	testFS.OptionString([]string{"profile"}, "", "Profile")
+	testFS.OptionBool([]string{"v", "verbose"}, false, "Verbose output")
+	if err := testFS.ApplyConfig("/etc/krait.conf", map[string]string{"o": "json", "level": "warn"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := root.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]Source{
+		"count":   {Kind: SourceCommandLine, Position: 2},
+		"level":   {Kind: SourceEnv, Name: "KRAIT_TEST_LEVEL", Position: -1},
+		"output":  {Kind: SourceConfig, Name: "/etc/krait.conf", Position: -1},
+		"profile": {Kind: SourceDefault, Position: -1},
+		"verbose": {Kind: SourceCommandLine, Position: 4},
+	}
+	for name, want := range tests {
+		o := testFS.Options[name]
+		if got := o.Source(); got != want || o.IsSet() != (want.Kind != SourceDefault) {
+			t.Errorf("%s got: %+v (set %t) | want: %+v", name, got, o.IsSet(), want)
+		}
+	}
+	if got, _ := testFS.Options["output"].GetString(); got != "json" {
+		t.Errorf("output got: %q | want: %q", got, "json")
+	}
+
+	var visited []string
+	testFS.VisitSet(func(name string, o Option) {
+		visited = append(visited, name)
+	})
+	if want := []string{"count", "level", "output", "verbose"}; fmt.Sprint(visited) != fmt.Sprint(want) {
+		t.Errorf("VisitSet got: %q | want: %q", visited, want)
+	}
+
+	// The state is cleared when the FlagSet is parsed again
+	if _, err := root.Parse([]string{"krait", "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if o := testFS.Options["count"]; o.IsSet() {
+		t.Errorf("count still set from %v", o.Source())
+	}
+}
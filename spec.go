@@ -0,0 +1,238 @@
+package krait
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Spec describes a root FlagSet and its command tree as JSON, for example
+//
+//	{
+//		"name": "myapp",
+//		"appLabel": "MyApp v0.1.0",
+//		"commands": [
+//			{
+//				"name": "test",
+//				"aliases": ["t"],
+//				"summary": "Tests the basic usage of Krait",
+//				"nargs": -1,
+//				"options": [
+//					{"aliases": ["c", "count"], "type": "int", "default": 3, "usage": "What number will invoke 'The Count'"}
+//				]
+//			}
+//		]
+//	}
+type Spec struct {
+	AppLabel          string        `json:"appLabel,omitempty"`
+	Commands          []CommandSpec `json:"commands,omitempty"`
+	DefaultSubCommand *string       `json:"defaultSubcommand,omitempty"` // "help" when omitted
+	Epilogue          string        `json:"epilogue,omitempty"`
+	ErrorHandling     string        `json:"errorHandling,omitempty"` // "continue", "exit" (default) or "panic"
+	Name              string        `json:"name"`
+	Options           []OptionSpec  `json:"options,omitempty"` // Rejected as root options are never parsed
+}
+
+// CommandSpec describes a subcommand of a Spec
+type CommandSpec struct {
+	Aliases  []string      `json:"aliases,omitempty"`
+	Commands []CommandSpec `json:"commands,omitempty"`
+	Epilogue string        `json:"epilogue,omitempty"`
+	NArgs    *int          `json:"nargs,omitempty"` // -1 (any number of arguments) when omitted
+	Name     string        `json:"name"`
+	Options  []OptionSpec  `json:"options,omitempty"`
+	Summary  string        `json:"summary,omitempty"`
+}
+
+// OptionSpec describes an option of a Spec or CommandSpec. The Type is one of
+// the Option* type names such as "int" and defaults to "string".
+type OptionSpec struct {
+	Aliases  []string        `json:"aliases"`
+	Default  json.RawMessage `json:"default,omitempty"`
+	Env      string          `json:"env,omitempty"`
+	NoValue  *string         `json:"noValue,omitempty"` // Makes a string option-argument optional, see OptionStringOptional
+	Required bool            `json:"required,omitempty"`
+	Type     string          `json:"type,omitempty"`
+	Usage    string          `json:"usage,omitempty"`
+}
+
+// errorHandlingNames maps the Spec ErrorHandling names to their modes
+var errorHandlingNames = map[string]flag.ErrorHandling{
+	"continue": ContinueOnError,
+	"exit":     ExitOnError,
+	"panic":    PanicOnError,
+}
+
+// Command returns the subcommand at the path of subcommand names or aliases
+// below this FlagSet, such as fs.Command("remote", "add"). Handlers can be
+// attached to a tree built with FromSpec this way.
+func (fs *FlagSet) Command(path ...string) (cmd *FlagSet, ok bool) {
+	cmd = fs
+	for _, name := range path {
+		subcmd, found := cmd.argIsSubcommand(name)
+		if !found {
+			return nil, false
+		}
+		cmd = cmd.subcommands[subcmd]
+	}
+	return cmd, true
+}
+
+// FromSpec builds a root FlagSet and its command tree from a JSON Spec
+func FromSpec(r io.Reader) (fs *FlagSet, err error) {
+	var spec Spec
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	return spec.FlagSet()
+}
+
+// FlagSet builds the root FlagSet and command tree described by the Spec
+func (spec Spec) FlagSet() (fs *FlagSet, err error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("invalid spec: missing name")
+	}
+	if len(spec.Options) > 0 {
+		return nil, fmt.Errorf("invalid spec: options of the root command are not supported, define them on a command")
+	}
+
+	errorHandling := ExitOnError
+	if spec.ErrorHandling != "" {
+		var ok bool
+		if errorHandling, ok = errorHandlingNames[strings.ToLower(spec.ErrorHandling)]; !ok {
+			return nil, fmt.Errorf("invalid spec: unknown errorHandling %q", spec.ErrorHandling)
+		}
+	}
+
+	fs = NewFlagSet(spec.Name, errorHandling)
+	fs.AppLabel = spec.AppLabel
+	fs.Epilogue = spec.Epilogue
+	if spec.DefaultSubCommand != nil {
+		fs.DefaultSubCommand = *spec.DefaultSubCommand
+	}
+
+	for _, cmd := range spec.Commands {
+		if err = fs.specCommand(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	return fs, nil
+}
+
+// specCommand adds the subcommand described by cmd to this FlagSet
+func (fs *FlagSet) specCommand(cmd CommandSpec) (err error) {
+	if cmd.Name == "" {
+		return fmt.Errorf("invalid spec: command of %q is missing a name", strings.Join(fs.getCommandList(), " "))
+	}
+	for _, name := range append([]string{strings.ToLower(cmd.Name)}, cmd.Aliases...) {
+		if owner, exists := fs.commandOwner(name); exists {
+			return fmt.Errorf("invalid spec: command %q conflicts with %q", strings.Join(append(fs.getCommandList(), name), " "), strings.Join(owner.getCommandList(), " "))
+		}
+	}
+
+	sub := fs.NewFlagSet(cmd.Name)
+	sub.Epilogue = cmd.Epilogue
+	sub.Summery = cmd.Summary
+	if cmd.NArgs != nil {
+		sub.NArgs = *cmd.NArgs
+	}
+	if len(cmd.Aliases) > 0 {
+		sub.SubcommandAlias(cmd.Aliases...)
+	}
+
+	if err = sub.specOptions(cmd.Options); err != nil {
+		return err
+	}
+	for _, child := range cmd.Commands {
+		if err = sub.specCommand(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// specOptions defines the options described by options on this FlagSet
+func (fs *FlagSet) specOptions(options []OptionSpec) (err error) {
+	for _, o := range options {
+		if len(o.Aliases) == 0 {
+			return fmt.Errorf("invalid spec: option of %q is missing aliases", strings.Join(fs.getCommandList(), " "))
+		}
+		name := strings.Join(o.Aliases, ", ")
+		for _, alias := range o.Aliases {
+			if canonical, exists := fs.optionAliasConflict(alias); exists {
+				return fmt.Errorf("invalid spec: option %s conflicts with %s", name, canonical)
+			}
+		}
+
+		// Unmarshal the default into a value of the option type
+		var def any
+		switch o.Type {
+		case OptionBool:
+			def = new(bool)
+		case OptionFloat, "float":
+			def = new(float64)
+		case OptionInt:
+			def = new(int)
+		case OptionMap:
+			def = new(map[string]string)
+		case OptionString, "":
+			def = new(string)
+		case OptionUint:
+			def = new(uint)
+		default:
+			return fmt.Errorf("invalid spec: option %s has unknown type %q", name, o.Type)
+		}
+		if len(o.Default) > 0 {
+			if err = json.Unmarshal(o.Default, def); err != nil {
+				return fmt.Errorf("invalid spec: option %s default: %w", name, err)
+			}
+		}
+		if o.NoValue != nil {
+			if _, ok := def.(*string); !ok {
+				return fmt.Errorf("invalid spec: option %s: noValue is only supported for string options", name)
+			}
+		}
+
+		switch v := def.(type) {
+		case *bool:
+			fs.OptionBool(o.Aliases, *v, o.Usage)
+		case *float64:
+			fs.OptionFloat(o.Aliases, *v, o.Usage)
+		case *int:
+			fs.OptionInt(o.Aliases, *v, o.Usage)
+		case *map[string]string:
+			m := fs.OptionMap(o.Aliases, o.Usage)
+			for key, value := range *v {
+				m[key] = value
+			}
+			canonical, _ := fs.optionName(o.Aliases[0])
+			f := fs.flagSet.Lookup(canonical)
+			f.DefValue = f.Value.String()
+		case *string:
+			if o.NoValue != nil {
+				fs.OptionStringOptional(o.Aliases, *v, *o.NoValue, o.Usage)
+			} else {
+				fs.OptionString(o.Aliases, *v, o.Usage)
+			}
+		case *uint:
+			fs.OptionUint(o.Aliases, *v, o.Usage)
+		}
+
+		if o.Env != "" {
+			fs.Env(o.Aliases[0], o.Env)
+		}
+		if o.Required {
+			fs.Require(o.Aliases[0])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package krait
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testSpec = `{
+	"name": "krait",
+	"appLabel": "Krait Test",
+	"errorHandling": "continue",
+	"commands": [
+		{
+			"name": "remote",
+			"summary": "Manage remotes",
+			"nargs": 0,
+			"commands": [
+				{
+					"name": "add",
+					"aliases": ["a"],
+					"summary": "Add a remote",
+					"nargs": 2,
+					"options": [
+						{"aliases": ["t", "track"], "type": "map", "default": {"branch": "main"}, "usage": "Branches to track"},
+						{"aliases": ["p", "port"], "type": "uint", "default": 22, "usage": "Port"},
+						{"aliases": ["color"], "noValue": "auto", "usage": "Colorize output"}
+					]
+				}
+			]
+		}
+	]
+}`
+
+// TestFromSpec ensure a command tree is built from JSON and handlers can be
+// attached by command path
+func TestFromSpec(t *testing.T) {
+	root, err := FromSpec(strings.NewReader(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addFS, ok := root.Command("remote", "a")
+	if !ok {
+		t.Fatal("command remote add not found")
+	}
+	var got string
+	addFS.CmdFunc = func(fs *FlagSet, args ...string) {
+		track, _ := fs.Options["track"].GetMap()
+		got = fmt.Sprintf("%v %d %s %q", track, *fs.Options["port"].value.(*uint), *fs.Options["color"].value.(*string), fs.Args())
+	}
+
+	if _, err = root.Parse([]string{"krait", "remote", "add", "--color", "origin", "example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `map[branch:main] 22 auto ["origin" "example.com"]`; got != want {
+		t.Fatalf("got: %s | want: %s", got, want)
+	}
+	if root.AppLabel != "Krait Test" || root.ErrorHandling() != ContinueOnError {
+		t.Fatalf("got: %q %v", root.AppLabel, root.ErrorHandling())
+	}
+
+	for spec, want := range map[string]string{
+		`{"name": "krait", "commands": [{"name": "x", "options": [{"aliases": ["n"], "type": "int", "default": "one"}]}]}`: "invalid spec: option n default",
+		`{"name": "krait", "commands": [{"name": "x", "options": [{"aliases": ["n"], "type": "duration"}]}]}`:              `unknown type "duration"`,
+		`{"name": "krait", "comands": []}`:                              `unknown field "comands"`,
+		`{"name": "krait", "options": [{"aliases": ["v", "verbose"]}]}`: "options of the root command are not supported",
+	} {
+		if _, err = FromSpec(strings.NewReader(spec)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s got: %v | want: %s", spec, err, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package krait
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// commandAvailable reports if the subcommand may be used, experimental
+// subcommands are only available once enabled
+func (fs *FlagSet) commandAvailable() bool {
+	return !fs.Experimental || fs.experimentalEnabled()
+}
+
+// commandVisible reports if the subcommand is listed in help and offered
+// for completion. A subcommand is hidden along with its parents.
+func (fs *FlagSet) commandVisible() bool {
+	for f := fs; f.parent != nil; f = f.parent {
+		if f.Hidden || !f.commandAvailable() {
+			return false
+		}
+	}
+	return true
+}
+
+// DeprecateOption marks the named option as deprecated. It still works but
+// using it reports the message, which may point at its replacement such as
+// "use --output instead". Any alias may be used to name the option.
+func (fs *FlagSet) DeprecateOption(name string, message string) {
+	canonical, ok := fs.optionName(name)
+	if !ok {
+		panic(fmt.Sprintf("cannot deprecate undefined option %q", name))
+	}
+	o := fs.Options[canonical]
+	o.deprecated = message
+	fs.Options[canonical] = o
+}
+
+// Deprecated returns the deprecation message of the option, an empty string
+// if it is not deprecated
+func (o Option) Deprecated() string {
+	return o.deprecated
+}
+
+// Experimental returns true if the option is only available when
+// experimental features are enabled
+func (o Option) Experimental() bool {
+	return o.experimental
+}
+
+// EnableExperimentalOption adds a root option such as --experimental that
+// enables experimental subcommands and options for the command line it is
+// given on. It must directly follow the root command name, e.g.
+// "myapp --experimental newcmd".
+func (fs *FlagSet) EnableExperimentalOption(name string) {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t=") {
+		panic(fmt.Sprintf("invalid experimental option name %q", name))
+	}
+	fs.getRoot().experimentalOpt = name
+}
+
+// experimentalEnabled reports if experimental subcommands and options are
+// enabled on the root FlagSet, by its experimental option or by its
+// ExperimentalEnv variable
+func (fs *FlagSet) experimentalEnabled() bool {
+	rfs := fs.getRoot()
+	if rfs.AllowExperimental || rfs.experimentalOn {
+		return true
+	}
+	if rfs.ExperimentalEnv != "" {
+		enabled, _ := strconv.ParseBool(os.Getenv(rfs.ExperimentalEnv))
+		return enabled
+	}
+	return false
+}
+
+// experimentalHint tells how to enable experimental features for errors
+func (fs *FlagSet) experimentalHint() string {
+	rfs := fs.getRoot()
+
+	var hints []string
+	if rfs.ExperimentalEnv != "" {
+		hints = append(hints, fmt.Sprintf("set %s=1", rfs.ExperimentalEnv))
+	}
+	if rfs.experimentalOpt != "" {
+		hints = append(hints, fmt.Sprintf("use %s --%s", rfs.cmd, rfs.experimentalOpt))
+	}
+	if len(hints) == 0 {
+		return ""
+	}
+	return "; " + strings.Join(hints, " or ") + " to enable it"
+}
+
+// ExperimentalOption marks the named options as experimental. They are
+// unknown options unless experimental features are enabled with
+// AllowExperimental, ExperimentalEnv or EnableExperimentalOption on the root
+// FlagSet. Any alias may be used to name an option.
+func (fs *FlagSet) ExperimentalOption(names ...string) {
+	for _, alias := range names {
+		name, ok := fs.optionName(alias)
+		if !ok {
+			panic(fmt.Sprintf("cannot mark undefined option %q as experimental", alias))
+		}
+		o := fs.Options[name]
+		o.experimental = true
+		fs.Options[name] = o
+	}
+}
+
+// parseExperimentalOption removes the experimental option from directly
+// after the root command name and records that it was given
+func (fs *FlagSet) parseExperimentalOption(args []string) []string {
+	if fs.experimentalOpt == "" {
+		return args
+	}
+	for len(args) > 1 && args[1] == "--"+fs.experimentalOpt {
+		fs.experimentalOn = true
+		args = append(args[:1], args[2:]...)
+	}
+	return args
+}
+
+// Hidden returns true if the option is omitted from help and completion
+func (o Option) Hidden() bool {
+	return o.hidden
+}
+
+// HideOption omits the named options from help and completion, they are
+// still parsed. Any alias may be used to name an option.
+func (fs *FlagSet) HideOption(names ...string) {
+	for _, alias := range names {
+		name, ok := fs.optionName(alias)
+		if !ok {
+			panic(fmt.Sprintf("cannot hide undefined option %q", alias))
+		}
+		o := fs.Options[name]
+		o.hidden = true
+		fs.Options[name] = o
+	}
+}
+
+// optionAvailable reports if the option may be used, experimental options
+// are only available once enabled
+func (fs *FlagSet) optionAvailable(name string) bool {
+	return !fs.Options[name].experimental || fs.experimentalEnabled()
+}
+
+// optionVisible reports if the option is listed in help and offered for
+// completion
+func (fs *FlagSet) optionVisible(name string) bool {
+	name, _, _ = strings.Cut(strings.TrimLeft(name, "-"), "=")
+	return !fs.Options[name].hidden && fs.optionAvailable(name)
+}
+
+// visibleSubcommandNames returns the sorted names and aliases of the
+// subcommands of this FlagSet that are not hidden
+func (fs *FlagSet) visibleSubcommandNames() (names []string) {
+	for _, name := range fs.subcommandNames() {
+		if sub, ok := fs.Command(name); ok && sub.commandVisible() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// warnDeprecated reports the deprecated subcommands of the active command
+// chain and the deprecated options that were set
+func (fs *FlagSet) warnDeprecated() {
+	rfs := fs.getRoot()
+	warn := rfs.WarnDeprecated
+	if warn == nil {
+		warn = func(name string, message string) {
+			fmt.Fprintf(rfs.flagSet.Output(), "warning: %s is deprecated: %s\n", name, message)
+		}
+	}
+
+	for _, cfs := range fs.getCommandChain() {
+		if cfs.Deprecated != "" {
+			warn(strings.Join(cfs.getCommandList(), " "), cfs.Deprecated)
+		}
+
+		cfs.VisitSet(func(name string, o Option) {
+			if o.deprecated != "" {
+				warn(cfs.optionAliases["--"+name], o.deprecated)
+			}
+		})
+	}
+}
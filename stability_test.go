@@ -0,0 +1,124 @@
+package krait
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestHidden ensure hidden subcommands and options parse but are not offered
+func TestHidden(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	testFS := root.NewFlagSet("test")
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	debug := testFS.OptionBool([]string{"debug"}, false, "Debug output")
+	testFS.HideOption("debug")
+	debugFS := root.NewFlagSet("debug")
+	debugFS.Hidden = true
+
+	sh := root.EnableShell()
+	if got := fmt.Sprint(sh.Complete("de")); got != "[]" {
+		t.Errorf("commands got: %s | want: []", got)
+	}
+	if got := fmt.Sprint(sh.Complete("test --")); got != "[--count]" {
+		t.Errorf("options got: %s | want: [--count]", got)
+	}
+
+	if subcmd, err := root.Parse([]string{"krait", "test", "--debug"}); err != nil || subcmd != "test" || !*debug {
+		t.Errorf("got: %q %t (%v)", subcmd, *debug, err)
+	}
+	if subcmd, err := root.Parse([]string{"krait", "debug"}); err != nil || subcmd != "debug" {
+		t.Errorf("got: %q (%v)", subcmd, err)
+	}
+}
+
+// TestExperimental ensure experimental subcommands and options are only known once enabled
+func TestExperimental(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	root.ExperimentalEnv = "KRAIT_EXPERIMENTAL"
+	testFS := root.NewFlagSet("test")
+	testFS.OptionBool([]string{"turbo"}, false, "Go faster")
+	testFS.ExperimentalOption("turbo")
+	root.NewFlagSet("beta").Experimental = true
+
+	tests := []struct {
+		env  string
+		args []string
+		want string
+	}{
+		{"", []string{"krait", "beta"}, `experimental command "beta" for "krait" is not enabled; set KRAIT_EXPERIMENTAL=1 to enable it`},
+		{"", []string{"krait", "test", "--turbo"}, `experimental option --turbo for "krait test" is not enabled; set KRAIT_EXPERIMENTAL=1 to enable it`},
+		{"1", []string{"krait", "beta"}, ""},
+		{"true", []string{"krait", "test", "--turbo"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("KRAIT_EXPERIMENTAL", tt.env)
+		_, err := root.Parse(tt.args)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want || (err != nil && !errors.Is(err, ErrUnknownCommand) && !errors.Is(err, ErrUnknownOption)) {
+			t.Errorf("%q got: %v | want: %s", tt.args, err, tt.want)
+		}
+	}
+}
+
+// TestExperimentalOption ensure the experimental option enables experimental
+// features for a single command line
+func TestExperimentalOption(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	root.EnableExperimentalOption("experimental")
+	testFS := root.NewFlagSet("test")
+	turbo := testFS.OptionBool([]string{"turbo"}, false, "Go faster")
+	testFS.ExperimentalOption("turbo")
+	root.NewFlagSet("beta").Experimental = true
+
+	if subcmd, err := root.Parse([]string{"krait", "--experimental", "beta"}); err != nil || subcmd != "beta" {
+		t.Errorf("got: %q (%v)", subcmd, err)
+	}
+	if _, err := root.Parse([]string{"krait", "--experimental", "test", "--turbo"}); err != nil || !*turbo {
+		t.Errorf("got: %t (%v)", *turbo, err)
+	}
+
+	want := `experimental command "beta" for "krait" is not enabled; use krait --experimental to enable it`
+	if _, err := root.Parse([]string{"krait", "beta"}); err == nil || err.Error() != want {
+		t.Errorf("got: %v | want: %s", err, want)
+	}
+	if _, err := root.Parse([]string{"krait", "test", "--experimental"}); !errors.Is(err, ErrUnknownOption) {
+		t.Errorf("got: %v | want: %v", err, ErrUnknownOption)
+	}
+}
+
+// TestDeprecated ensure deprecated subcommands and options still work with a warning
+func TestDeprecated(t *testing.T) {
+	var warnings []string
+
+	root := NewFlagSet("krait", ContinueOnError)
+	root.WarnDeprecated = func(name string, message string) {
+		warnings = append(warnings, name+": "+message)
+	}
+	oldFS := root.NewFlagSet("old")
+	oldFS.Deprecated = `use "krait new" instead`
+	oldFS.OptionString([]string{"o", "out"}, "", "Output file")
+	oldFS.OptionString([]string{"output"}, "", "Output file")
+	oldFS.DeprecateOption("o", "use --output instead")
+
+	if _, err := root.Parse([]string{"krait", "old", "--output", "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := root.Parse([]string{"krait", "old", "-o", "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`krait old: use "krait new" instead`,
+		`krait old: use "krait new" instead`,
+		`--out: use --output instead`,
+	}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got: %q | want: %q", warnings, want)
+	}
+}
@@ -0,0 +1,89 @@
+package krait
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// suggestionDistance is the largest edit distance at which a candidate is
+// suggested for an unknown word
+const suggestionDistance = 2
+
+// damerauLevenshtein returns the optimal string alignment distance between a
+// and b. Insertions, deletions, substitutions and transpositions of adjacent
+// characters each cost one edit.
+func damerauLevenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+cost)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// didYouMean formats suggestions as the tail of an error message
+func didYouMean(suggestions []string) string {
+	switch len(suggestions) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("; did you mean %s?", suggestions[0])
+	default:
+		return fmt.Sprintf("; did you mean one of %s?", strings.Join(suggestions, ", "))
+	}
+}
+
+func minInt(n int, others ...int) int {
+	for _, o := range others {
+		if o < n {
+			n = o
+		}
+	}
+	return n
+}
+
+// suggestions returns the candidates within suggestionDistance edits of word,
+// or that word is a prefix of, ordered by distance then name
+func suggestions(word string, candidates []string) (result []string) {
+	word = strings.ToLower(word)
+	distances := make(map[string]int)
+
+	for _, c := range candidates {
+		if _, seen := distances[c]; seen {
+			continue
+		}
+		d := damerauLevenshtein(word, strings.ToLower(c))
+		if d <= suggestionDistance || (len(word) > 1 && strings.HasPrefix(strings.ToLower(c), word)) {
+			distances[c] = d
+			result = append(result, c)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if distances[result[i]] != distances[result[j]] {
+			return distances[result[i]] < distances[result[j]]
+		}
+		return result[i] < result[j]
+	})
+
+	return result
+}
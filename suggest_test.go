@@ -0,0 +1,79 @@
+package krait
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDamerauLevenshtein ensure edit distances count transpositions as one edit
+func TestDamerauLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"test", "test", 0},
+		{"tset", "test", 1},
+		{"tst", "test", 1},
+		{"tesst", "test", 1},
+		{"version", "verison", 1},
+		{"help", "yelp", 1},
+		{"abc", "", 3},
+	}
+
+	for _, tt := range tests {
+		if got := damerauLevenshtein(tt.a, tt.b); got != tt.want {
+			t.Fatalf("%q %q got: %d | want: %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestSuggestions ensure suggestions are ordered by distance then name
+func TestSuggestions(t *testing.T) {
+	want := "test, text, testing"
+	got := strings.Join(suggestions("tes", []string{"help", "testing", "text", "test", "version"}), ", ")
+
+	if got != want {
+		t.Fatalf("got: %q | want: %q", got, want)
+	}
+}
+
+// TestUnknownCommand ensure unknown subcommands are errors with suggestions
+func TestUnknownCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"krait", "tset"}, `invalid command "tset" for "krait"; did you mean "test"?`},
+		{[]string{"krait", "remote", "ad"}, `invalid command "ad" for "krait remote"; did you mean one of "add", "rm"?`},
+		{[]string{"krait", "zzzzzz"}, `invalid command "zzzzzz" for "krait"`},
+	}
+
+	for _, tt := range tests {
+		root := NewFlagSet("krait", ContinueOnError)
+		root.NewFlagSet("test")
+		remoteFS := root.NewFlagSet("remote")
+		remoteFS.NArgs = 0
+		remoteFS.NewFlagSet("add")
+		rmFS := remoteFS.NewFlagSet("remove")
+		rmFS.SubcommandAlias("rm")
+
+		_, err := root.Parse(tt.args)
+		if err == nil || err.Error() != tt.want {
+			t.Fatalf("%q got: %v | want: %s", tt.args, err, tt.want)
+		}
+	}
+}
+
+// TestUnknownOption ensure unknown options are errors with suggestions
+func TestUnknownOption(t *testing.T) {
+	want := `unknown option "--cuont" for "krait test"; did you mean --count?`
+
+	root := NewFlagSet("krait")
+	testFS := root.NewFlagSet("test", ContinueOnError)
+	testFS.OptionInt([]string{"c", "count"}, 0, "What number will invoke 'The Count'")
+	_, err := root.Parse([]string{"krait", "test", "--cuont=3"})
+
+	if err == nil || err.Error() != want {
+		t.Fatalf("got: %v | want: %s", err, want)
+	}
+}
@@ -0,0 +1,149 @@
+package krait
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reservedOptions are the option names Parse treats as a help request
+var reservedOptions = []string{"h", "help"}
+
+// commandOwner returns the subcommand of this FlagSet using name as its
+// name or one of its aliases. Subcommands of other parents may share names.
+func (fs *FlagSet) commandOwner(name string) (owner *FlagSet, ok bool) {
+	if owner, ok = fs.subcommands[name]; ok {
+		return owner, ok
+	}
+	if sub, found := fs.subcmdAliases[name]; found {
+		owner, ok = fs.subcommands[sub]
+	}
+	return owner, ok
+}
+
+// commandNameProblem describes why name is not usable as a subcommand name
+// or alias, or returns an empty string
+func commandNameProblem(name string) string {
+	switch {
+	case name == "":
+		return "is empty"
+	case strings.HasPrefix(name, "-"):
+		return "starts with -"
+	case strings.HasPrefix(name, "@"):
+		return "starts with @"
+	case strings.ContainsAny(name, " \t\n="):
+		return "contains whitespace or ="
+	case strings.ToLower(name) != name:
+		return "is not lowercase"
+	}
+	return ""
+}
+
+// optionAliasConflict returns the canonical prefixed name of the option
+// already using alias, given without a prefix. The implied --no-<name>
+// negations of boolean options never conflict.
+func (fs *FlagSet) optionAliasConflict(alias string) (canonical string, ok bool) {
+	for _, prefixed := range []string{"-" + alias, "--" + alias} {
+		if canonical, ok = fs.lookupOption(prefixed); ok && !strings.Contains(canonical, "=") {
+			return canonical, ok
+		}
+	}
+	return "", false
+}
+
+// optionNameProblem describes why alias is not usable as an option alias,
+// or returns an empty string
+func optionNameProblem(alias string) string {
+	switch {
+	case alias == "":
+		return "is empty"
+	case strings.HasPrefix(alias, "-"):
+		return "starts with -"
+	case strings.ContainsAny(alias, " \t\n="):
+		return "contains whitespace or ="
+	}
+	return ""
+}
+
+// Validate lints the FlagSet and its subcommands, reporting every problem
+// found in a single error: invalid characters in command names, aliases and
+// option aliases, subcommands without a summary, options using the reserved
+// -h and --help, duplicate named arguments and user aliases hidden by a
+// command. Conflicting names are already rejected when they are defined.
+func (fs *FlagSet) Validate() (err error) {
+	var problems []string
+	fs.validate(&problems)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid command tree: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validate appends the problems of this FlagSet and its subcommands
+func (fs *FlagSet) validate(problems *[]string) {
+	path := strings.Join(fs.getCommandList(), " ")
+	report := func(format string, a ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, a...))
+	}
+
+	if fs.parent != nil {
+		if problem := commandNameProblem(fs.cmd); problem != "" {
+			report("command name %s", problem)
+		}
+		if fs.Summery == "" {
+			report("missing summary")
+		}
+		for _, alias := range fs.getSubCommandAliases() {
+			if problem := commandNameProblem(alias); problem != "" {
+				report("alias %q %s", alias, problem)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(fs.Options))
+	for name := range fs.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, alias := range fs.Options[name].Aliases() {
+			if problem := optionNameProblem(alias); problem != "" {
+				report("option alias %q %s", alias, problem)
+			}
+			for _, reserved := range reservedOptions {
+				if alias == reserved {
+					report("option alias %q is reserved for help", alias)
+				}
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, spec := range fs.argSpecs {
+		if seen[spec.name] {
+			report("duplicate argument %s", spec.name)
+		}
+		seen[spec.name] = true
+	}
+
+	userAliases := make([]string, 0, len(fs.userAliases))
+	for alias := range fs.userAliases {
+		userAliases = append(userAliases, alias)
+	}
+	sort.Strings(userAliases)
+	for _, alias := range userAliases {
+		if _, ok := fs.argIsSubcommand(alias); ok {
+			report("user alias %q is hidden by a command", alias)
+		}
+	}
+
+	subcommands := make([]string, 0, len(fs.subcommands))
+	for name := range fs.subcommands {
+		subcommands = append(subcommands, name)
+	}
+	sort.Strings(subcommands)
+	for _, name := range subcommands {
+		fs.subcommands[name].validate(problems)
+	}
+}
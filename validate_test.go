@@ -0,0 +1,127 @@
+package krait
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestRegistrationConflicts ensure conflicting names panic when they are defined
+func TestRegistrationConflicts(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(root *FlagSet)
+		want   string
+	}{
+		{"option alias", func(root *FlagSet) {
+			testFS := root.NewFlagSet("test")
+			testFS.OptionInt([]string{"c", "count"}, 0, "Count")
+			testFS.OptionString([]string{"c", "color"}, "", "Color")
+		}, `cannot define option alias "c" for "krait test": already used by --count`},
+		{"option name", func(root *FlagSet) {
+			testFS := root.NewFlagSet("test")
+			testFS.OptionString([]string{"c", "color"}, "", "Color")
+			testFS.OptionString([]string{"colour", "color"}, "", "Colour")
+		}, `cannot define option alias "color" for "krait test": already used by --color`},
+		{"subcommand", func(root *FlagSet) {
+			root.NewFlagSet("test")
+			root.NewFlagSet("Test")
+		}, `cannot define subcommand "test" for "krait": already used by "krait test"`},
+		{"built-in alias", func(root *FlagSet) {
+			root.NewFlagSet("history").SubcommandAlias("hlp")
+		}, `cannot define alias "hlp" for "krait history": already used by "krait help"`},
+		{"built-in subcommand", func(root *FlagSet) {
+			root.NewFlagSet("verify").SubcommandAlias("v")
+			root.NewFlagSet("ver")
+		}, `cannot define subcommand "ver" for "krait": already used by "krait version"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if got := fmt.Sprint(recover()); got != tt.want {
+					t.Errorf("got: %s | want: %s", got, tt.want)
+				}
+			}()
+			tt.define(NewFlagSet("krait", ContinueOnError))
+		})
+	}
+}
+
+// TestSiblingSubcommands ensure subcommands of different parents may share names and aliases
+func TestSiblingSubcommands(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("got: %v | want: no panic", r)
+		}
+	}()
+
+	var ran []string
+
+	root := NewFlagSet("krait", ContinueOnError)
+	for _, name := range []string{"remote", "tag"} {
+		parentFS := root.NewFlagSet(name)
+		parentFS.Summery = "Manage " + name + "s"
+		addFS := parentFS.NewFlagSet("add")
+		addFS.Summery = "Add a " + name
+		addFS.SubcommandAlias("a")
+		addFS.OptionBool([]string{"f", "force"}, false, "Replace an existing "+name)
+		addFS.CmdFunc = func(fs *FlagSet, args ...string) {
+			ran = append(ran, fs.parent.cmd+" "+fs.cmd)
+		}
+	}
+
+	if err := root.Validate(); err != nil {
+		t.Errorf("got: %v | want: <nil>", err)
+	}
+
+	for _, args := range [][]string{{"krait", "tag", "add"}, {"krait", "remote", "a"}, {"krait", "tag", "a", "--", "add"}} {
+		if _, err := root.Parse(args); err != nil {
+			t.Errorf("%q got: %v", args, err)
+		}
+	}
+	want := []string{"tag add", "remote add", "tag add"}
+	if strings.Join(ran, ",") != strings.Join(want, ",") {
+		t.Errorf("got: %q | want: %q", ran, want)
+	}
+
+	sh := root.EnableShell()
+	if got := fmt.Sprint(sh.Complete("tag a --f")); got != "[--force]" {
+		t.Errorf("complete got: %s | want: [--force]", got)
+	}
+}
+
+// TestValidate ensure every problem in the tree is reported
+func TestValidate(t *testing.T) {
+	root := NewFlagSet("krait", ContinueOnError)
+	testFS := root.NewFlagSet("test")
+	testFS.Summery = "Tests the basic usage of Krait"
+	testFS.OptionBool([]string{"h", "human"}, false, "Human readable output")
+	testFS.Arg("FILE", "File to test")
+	testFS.Arg("FILE", "Other file to test")
+	remoteFS := root.NewFlagSet("remote")
+	remoteFS.SubcommandAlias("Rmt")
+	root.UserAlias("st", "test -h")
+	root.NewFlagSet("st").Summery = "Shows the status"
+
+	want := []string{
+		`krait: user alias "st" is hidden by a command`,
+		`krait remote: missing summary`,
+		`krait remote: alias "Rmt" is not lowercase`,
+		`krait test: option alias "h" is reserved for help`,
+		`krait test: duplicate argument FILE`,
+	}
+	err := root.Validate()
+	if err == nil {
+		t.Fatal("got: <nil> | want: invalid command tree")
+	}
+	for _, problem := range want {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("missing %q in %q", problem, err)
+		}
+	}
+
+	if err = NewFlagSet("krait").Validate(); err != nil {
+		t.Errorf("got: %v | want: <nil>", err)
+	}
+}